@@ -0,0 +1,40 @@
+package calcrpc
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// requireAPIKey wraps next so that requests must carry "Authorization: Bearer <apiKey>"
+// An empty apiKey disables the check; CORS preflight requests are always let through.
+// Rejections carry CORS headers for the origins in corsOrigins
+func requireAPIKey(apiKey string, corsOrigins []string, next http.Handler) http.Handler {
+	if apiKey == "" {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "OPTIONS" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(apiKey)) != 1 {
+			if origin := allowedOrigin(corsOrigins, r.Header.Get("Origin")); origin != "" {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			w.Header().Add("Vary", "Origin")
+			w.Header().Set("WWW-Authenticate", `Bearer realm="jsonrpc"`)
+			writeJSONRPCError(w, http.StatusUnauthorized, &JSONRPCError{
+				Code:    Unauthorized,
+				Message: "Unauthorized",
+				Data:    "Missing or invalid bearer token",
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
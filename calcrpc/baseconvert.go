@@ -0,0 +1,48 @@
+package calcrpc
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// BaseConvertParams represents parameters for converting an integer between bases
+// Value is written in base From; the result is written in base To
+type BaseConvertParams struct {
+	Value string `json:"value"`
+	From  int    `json:"from"`
+	To    int    `json:"to"`
+}
+
+// Validate ensures both bases are in 2-36 and value is a valid int64 in base from
+func (p *BaseConvertParams) Validate() error {
+	if err := validateBase("from", p.From); err != nil {
+		return err
+	}
+	if err := validateBase("to", p.To); err != nil {
+		return err
+	}
+	if _, err := strconv.ParseInt(p.Value, p.From, 64); err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return invalidParams("value", reasonRange, "64-bit integer", fmt.Sprintf("Parameter 'value' %q is out of the 64-bit integer range", p.Value))
+		}
+		return invalidParams("value", reasonInvalid, fmt.Sprintf("base-%d integer", p.From), fmt.Sprintf("Parameter 'value' %q is not a valid base-%d integer", p.Value, p.From))
+	}
+	return nil
+}
+
+// validateBase returns InvalidParams unless base is supported by strconv (2-36)
+func validateBase(name string, base int) error {
+	if base < 2 || base > 36 {
+		return invalidParams(name, reasonRange, "integer 2-36", fmt.Sprintf("Parameter '%s' must be a base between 2 and 36, got %d", name, base))
+	}
+	return nil
+}
+
+// BaseConvert rewrites an integer from one base into another
+func (c *Calculator) BaseConvert(params BaseConvertParams) (string, error) {
+	n, _ := strconv.ParseInt(params.Value, params.From, 64)
+	result := strconv.FormatInt(n, params.To)
+	c.logger.Debug("calculation", "operation", "baseConvert", "value", params.Value, "from", params.From, "to", params.To, "result", result)
+	return result, nil
+}
@@ -0,0 +1,62 @@
+package calcrpc
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// BigParams represents parameters for exact integer operations
+// Operands are base-10 integer strings of any length
+type BigParams struct {
+	A string `json:"a"`
+	B string `json:"b"`
+}
+
+// Validate ensures both operands are base-10 integer strings
+func (p *BigParams) Validate() error {
+	if _, err := parseBigInt("a", p.A); err != nil {
+		return err
+	}
+	_, err := parseBigInt("b", p.B)
+	return err
+}
+
+// operands returns A and B as big integers
+func (p BigParams) operands() (*big.Int, *big.Int) {
+	a, _ := parseBigInt("a", p.A)
+	b, _ := parseBigInt("b", p.B)
+	return a, b
+}
+
+// parseBigInt parses a base-10 integer string parameter
+func parseBigInt(name string, value string) (*big.Int, error) {
+	n, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return nil, invalidParams(name, reasonInvalid, "base-10 integer string", fmt.Sprintf("Parameter '%s' must be a base-10 integer string, got %q", name, value))
+	}
+	return n, nil
+}
+
+// BigAdd adds arbitrarily large integers exactly
+func (c *Calculator) BigAdd(params BigParams) (string, error) {
+	a, b := params.operands()
+	result := new(big.Int).Add(a, b).String()
+	c.logger.Debug("calculation", "operation", "bigAdd", "a", params.A, "b", params.B, "result", result)
+	return result, nil
+}
+
+// BigSub subtracts arbitrarily large integers exactly
+func (c *Calculator) BigSub(params BigParams) (string, error) {
+	a, b := params.operands()
+	result := new(big.Int).Sub(a, b).String()
+	c.logger.Debug("calculation", "operation", "bigSub", "a", params.A, "b", params.B, "result", result)
+	return result, nil
+}
+
+// BigMul multiplies arbitrarily large integers exactly
+func (c *Calculator) BigMul(params BigParams) (string, error) {
+	a, b := params.operands()
+	result := new(big.Int).Mul(a, b).String()
+	c.logger.Debug("calculation", "operation", "bigMul", "a", params.A, "b", params.B, "result", result)
+	return result, nil
+}
@@ -0,0 +1,64 @@
+package calcrpc
+
+import "fmt"
+
+// IntUnaryParams represents parameters for single-operand 64-bit integer operations
+type IntUnaryParams struct {
+	Value int64 `json:"value"`
+}
+
+// ShiftParams represents parameters for shifting a by b bits
+type ShiftParams struct {
+	A int64 `json:"a"`
+	B int64 `json:"b"`
+}
+
+// Validate ensures the shift amount b is within 0-63
+func (p *ShiftParams) Validate() error {
+	if p.B < 0 || p.B > 63 {
+		return invalidParams("b", reasonRange, "integer 0-63", fmt.Sprintf("Parameter 'b' must be a shift amount between 0 and 63, got %d", p.B))
+	}
+	return nil
+}
+
+// And computes the bitwise AND of a and b
+func (c *Calculator) And(params IntParams) (int64, error) {
+	result := params.A & params.B
+	c.logger.Debug("calculation", "operation", "and", "a", params.A, "b", params.B, "result", result)
+	return result, nil
+}
+
+// Or computes the bitwise OR of a and b
+func (c *Calculator) Or(params IntParams) (int64, error) {
+	result := params.A | params.B
+	c.logger.Debug("calculation", "operation", "or", "a", params.A, "b", params.B, "result", result)
+	return result, nil
+}
+
+// Xor computes the bitwise exclusive OR of a and b
+func (c *Calculator) Xor(params IntParams) (int64, error) {
+	result := params.A ^ params.B
+	c.logger.Debug("calculation", "operation", "xor", "a", params.A, "b", params.B, "result", result)
+	return result, nil
+}
+
+// Not computes the bitwise complement of value
+func (c *Calculator) Not(params IntUnaryParams) (int64, error) {
+	result := ^params.Value
+	c.logger.Debug("calculation", "operation", "not", "value", params.Value, "result", result)
+	return result, nil
+}
+
+// ShiftLeft shifts a left by b bits; bits shifted past bit 63 are discarded
+func (c *Calculator) ShiftLeft(params ShiftParams) (int64, error) {
+	result := params.A << params.B
+	c.logger.Debug("calculation", "operation", "shiftLeft", "a", params.A, "b", params.B, "result", result)
+	return result, nil
+}
+
+// ShiftRight shifts a right by b bits, preserving the sign (arithmetic shift)
+func (c *Calculator) ShiftRight(params ShiftParams) (int64, error) {
+	result := params.A >> params.B
+	c.logger.Debug("calculation", "operation", "shiftRight", "a", params.A, "b", params.B, "result", result)
+	return result, nil
+}
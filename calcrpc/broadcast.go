@@ -0,0 +1,81 @@
+package calcrpc
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// StateChangedMethod is the notification pushed when the memory register changes
+const StateChangedMethod = "calc.stateChanged"
+
+// subscriberBuffer is how many pushed notifications a slow subscriber may lag behind
+// before further ones are dropped for it
+const subscriberBuffer = 16
+
+// broadcaster fans server-initiated notifications out to subscribers
+type broadcaster struct {
+	mu          sync.Mutex
+	subscribers map[chan []byte]struct{}
+}
+
+// StateChangedParams is the payload of a calc.stateChanged notification
+type StateChangedParams struct {
+	Memory float64 `json:"memory"`
+	Method string  `json:"method"` // the call that changed the state, e.g. "mem.add"
+}
+
+// Subscribe registers for server-pushed notifications, delivered as encoded
+// JSON-RPC notification messages. The returned function unsubscribes and closes the channel
+func (s *JSONRPCServer) Subscribe() (<-chan []byte, func()) {
+	ch := make(chan []byte, subscriberBuffer)
+
+	s.broadcast.mu.Lock()
+	if s.broadcast.subscribers == nil {
+		s.broadcast.subscribers = make(map[chan []byte]struct{})
+	}
+	s.broadcast.subscribers[ch] = struct{}{}
+	s.broadcast.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			s.broadcast.mu.Lock()
+			delete(s.broadcast.subscribers, ch)
+			s.broadcast.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Broadcast pushes a JSON-RPC notification to every subscriber
+// Delivery never blocks: subscribers whose buffer is full miss the notification
+func (s *JSONRPCServer) Broadcast(method string, params interface{}) {
+	message, err := json.Marshal(JSONRPCNotification{
+		JSONRPC: JSONRPCVersion,
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		s.logger.Error("failed to encode broadcast", "method", method, "error", err)
+		return
+	}
+
+	s.broadcast.mu.Lock()
+	defer s.broadcast.mu.Unlock()
+	for ch := range s.broadcast.subscribers {
+		select {
+		case ch <- message:
+		default:
+			s.logger.Warn("dropping broadcast for slow subscriber", "method", method)
+		}
+	}
+}
+
+// notifyStateChanged broadcasts the current memory register after a stateful call
+func (s *JSONRPCServer) notifyStateChanged(method string) {
+	s.calculator.mu.Lock()
+	memory := s.calculator.memory
+	s.calculator.mu.Unlock()
+
+	s.Broadcast(StateChangedMethod, StateChangedParams{Memory: memory, Method: method})
+}
@@ -0,0 +1,1054 @@
+package calcrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// serviceName is reported by getInfo and rpc.discover
+const serviceName = "JSON-RPC Calculator"
+
+// serviceVersion is reported by getInfo and rpc.discover. Bump it here when
+// the API changes, or stamp a release build with
+// -ldflags "-X simple-jsonrpc-calculator/calcrpc.serviceVersion=1.2.3"
+var serviceVersion = "1.2"
+
+// Calculator provides arithmetic operations and a memory register
+type Calculator struct {
+	logger *slog.Logger
+
+	// functions resolves function calls in Eval; nil uses the Calculator's own methods
+	functions exprFuncs
+
+	// maxExprDepth returns the nesting limit for Eval; nil uses DefaultMaxExprDepth
+	maxExprDepth func() int
+
+	mu     sync.Mutex // guards memory and undo; handlers run in parallel goroutines
+	memory float64
+	undo   []float64 // prior memory values, most recent last
+}
+
+// NewCalculator creates a Calculator with an empty memory register
+// A nil logger falls back to slog.Default()
+func NewCalculator(logger *slog.Logger) *Calculator {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Calculator{logger: logger}
+}
+
+// maxUndo bounds how many memory changes MemUndo can revert
+const maxUndo = 50
+
+// CalculatorParams represents parameters for binary operations
+// Fields without omitempty in their json tag are required by the dispatcher
+type CalculatorParams struct {
+	A float64 `json:"a"`
+	B float64 `json:"b"`
+}
+
+// UnmarshalJSON accepts a and b as JSON numbers or as strings holding a JSON
+// number ("10"), for clients that stringify form inputs
+func (p *CalculatorParams) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		A json.RawMessage `json:"a"`
+		B json.RawMessage `json:"b"`
+	}
+	// A custom unmarshaler doesn't inherit the caller's decoder settings
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&raw); err != nil {
+		return err
+	}
+
+	var a, b lenientNumber
+	if err := a.decodeField("a", raw.A); err != nil {
+		return err
+	}
+	if err := b.decodeField("b", raw.B); err != nil {
+		return err
+	}
+	p.A, p.B = float64(a), float64(b)
+	return nil
+}
+
+// lenientNumber is a float64 that may also be written as a numeric JSON string
+type lenientNumber float64
+
+// UnmarshalJSON decodes a JSON number, or a string containing one
+func (n *lenientNumber) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		data = []byte(text)
+	}
+
+	var f float64
+	if err := json.Unmarshal(data, &f); err != nil {
+		return &json.UnmarshalTypeError{Value: string(data), Type: reflect.TypeOf(f)}
+	}
+	*n = lenientNumber(f)
+	return nil
+}
+
+// decodeField decodes the named field, leaving n unchanged when it is absent
+func (n *lenientNumber) decodeField(field string, data json.RawMessage) error {
+	if data == nil {
+		return nil
+	}
+	if err := n.UnmarshalJSON(data); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			typeErr.Field = field
+		}
+		return err
+	}
+	return nil
+}
+
+// IntParams represents parameters for exact 64-bit integer operations
+type IntParams struct {
+	A int64 `json:"a"`
+	B int64 `json:"b"`
+}
+
+// UnaryParams represents parameters for single-operand operations
+type UnaryParams struct {
+	Value float64 `json:"value"`
+}
+
+// RoundParams represents parameters for rounding to a number of decimal digits
+type RoundParams struct {
+	Value  float64 `json:"value"`
+	Digits int     `json:"digits,omitempty"` // decimal places; negative rounds to tens, hundreds, ...
+}
+
+// AngleParams represents parameters for trigonometric operations
+type AngleParams struct {
+	Value   float64 `json:"value"`
+	Degrees bool    `json:"degrees,omitempty"` // Value is in degrees rather than radians
+}
+
+// radians returns the angle in radians
+func (p AngleParams) radians() float64 {
+	if p.Degrees {
+		return p.Value * math.Pi / 180
+	}
+	return p.Value
+}
+
+// VariadicParams represents parameters for operations over a list of numbers
+type VariadicParams struct {
+	Values []float64 `json:"values"`
+}
+
+// Validate ensures the values field was supplied
+func (p *VariadicParams) Validate() error {
+	if p.Values == nil {
+		return invalidParams("values", reasonRequired, "array of numbers", "Parameter 'values' is required and must be an array of numbers")
+	}
+	return nil
+}
+
+// ClampParams represents parameters for bounding a value to [min, max]
+type ClampParams struct {
+	Value float64 `json:"value"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+}
+
+// Validate ensures the bounds are ordered
+func (p *ClampParams) Validate() error {
+	if p.Min > p.Max {
+		return invalidParams("min", reasonRange, "number <= max", fmt.Sprintf("Parameter 'min' (%g) must not exceed 'max' (%g)", p.Min, p.Max))
+	}
+	return nil
+}
+
+// FactorialParams represents parameters for factorial
+type FactorialParams struct {
+	N float64 `json:"n"`
+}
+
+// Validate ensures n is a non-negative whole number
+func (p *FactorialParams) Validate() error {
+	return validateWholeNumber("n", p.N)
+}
+
+// CombinationsParams represents parameters for choosing r items from n
+type CombinationsParams struct {
+	N float64 `json:"n"`
+	R float64 `json:"r"`
+}
+
+// Validate ensures n and r are non-negative whole numbers with r <= n
+func (p *CombinationsParams) Validate() error {
+	if err := validateWholeNumber("n", p.N); err != nil {
+		return err
+	}
+	if err := validateWholeNumber("r", p.R); err != nil {
+		return err
+	}
+	if p.R > p.N {
+		return invalidParams("r", reasonRange, "integer <= n", fmt.Sprintf("Parameter 'r' (%g) must not exceed 'n' (%g)", p.R, p.N))
+	}
+	return nil
+}
+
+// validateWholeNumber returns InvalidParams unless v is a non-negative integer
+func validateWholeNumber(name string, v float64) error {
+	if v < 0 || v != math.Trunc(v) || math.IsInf(v, 0) {
+		return invalidParams(name, reasonInvalid, "non-negative whole number", fmt.Sprintf("Parameter '%s' must be a non-negative whole number, got %g", name, v))
+	}
+	return nil
+}
+
+// maxSafeInteger is the largest integer up to which float64 represents every integer exactly (2^53)
+const maxSafeInteger = 1 << 53
+
+// validateInteger returns InvalidParams unless v is a whole number within ±maxSafeInteger
+func validateInteger(name string, v float64) error {
+	if v != math.Trunc(v) || math.Abs(v) > maxSafeInteger {
+		return invalidParams(name, reasonRange, "whole number with magnitude <= 2^53", fmt.Sprintf("Parameter '%s' must be a whole number with magnitude at most 2^53, got %g", name, v))
+	}
+	return nil
+}
+
+// gcd computes the greatest common divisor of |a| and |b| with the Euclidean algorithm
+func gcd(a, b float64) float64 {
+	a, b = math.Abs(a), math.Abs(b)
+	for b != 0 {
+		a, b = b, math.Mod(a, b)
+	}
+	return a
+}
+
+// maxFactorial is the largest n whose factorial fits in a float64
+const maxFactorial = 170
+
+// maxCombinationsN bounds n in combinations to the integers a float64 holds exactly
+const maxCombinationsN = 1 << 53
+
+// ChainParams represents parameters for applying operations left to right
+type ChainParams struct {
+	Start float64   `json:"start"`
+	Ops   []ChainOp `json:"ops"`
+}
+
+// ChainOp is one step of a chain: the running value op value
+type ChainOp struct {
+	Op    string   `json:"op"`
+	Value *float64 `json:"value"` // required; a pointer so a missing value isn't read as 0
+}
+
+// chainOperations maps chain op names to the binary operation they apply
+var chainOperations = map[string]func(*Calculator, CalculatorParams) (float64, error){
+	"add":      (*Calculator).Add,
+	"subtract": (*Calculator).Subtract,
+	"multiply": (*Calculator).Multiply,
+	"divide":   (*Calculator).Divide,
+	"power":    (*Calculator).Power,
+	"modulo":   (*Calculator).Modulo,
+}
+
+// Validate ensures every step names a supported operation and has a value
+func (p *ChainParams) Validate() error {
+	for i, step := range p.Ops {
+		if _, ok := chainOperations[step.Op]; !ok {
+			return invalidParams(fmt.Sprintf("ops[%d].op", i), reasonInvalid, "add, subtract, multiply, divide, power or modulo", fmt.Sprintf("ops[%d]: unknown op %q (supported: add, subtract, multiply, divide, power, modulo)", i, step.Op))
+		}
+		if step.Value == nil {
+			return invalidParams(fmt.Sprintf("ops[%d].value", i), reasonRequired, "number", fmt.Sprintf("ops[%d]: value is required", i))
+		}
+	}
+	return nil
+}
+
+// EvalParams represents parameters for evaluating an arithmetic expression
+type EvalParams struct {
+	Expr string             `json:"expr"`
+	Vars map[string]float64 `json:"vars,omitempty"` // values for the variables referenced in expr
+}
+
+// Validate ensures every variable name is an identifier; names reserved for
+// functions are rejected by Eval, which knows the functions available
+func (p *EvalParams) Validate() error {
+	for _, name := range varNames(p.Vars) {
+		if !isIdentifier(name) {
+			return invalidParams("vars."+name, reasonInvalid, "identifier", fmt.Sprintf("Variable name %q must start with a letter or underscore and contain only letters, digits and underscores", name))
+		}
+	}
+	return nil
+}
+
+// varNames returns the names of vars in sorted order, so errors are stable
+func varNames(vars map[string]float64) []string {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// maxEvalBatch bounds the expressions in one evalBatch call
+const maxEvalBatch = 1000
+
+// EvalBatchParams represents parameters for evaluating several expressions
+type EvalBatchParams struct {
+	Exprs []string `json:"exprs"`
+}
+
+// Validate ensures exprs was supplied and is not too long
+func (p *EvalBatchParams) Validate() error {
+	if p.Exprs == nil {
+		return invalidParams("exprs", reasonRequired, "array of strings", "Parameter 'exprs' is required and must be an array of strings")
+	}
+	if len(p.Exprs) > maxEvalBatch {
+		return invalidParams("exprs", reasonRange, fmt.Sprintf("at most %d expressions", maxEvalBatch), fmt.Sprintf("Parameter 'exprs' may hold at most %d expressions, got %d", maxEvalBatch, len(p.Exprs)))
+	}
+	return nil
+}
+
+// EvalResult is the outcome of one expression of an evalBatch call:
+// its value, or the error evaluating it
+type EvalResult struct {
+	Result *float64      `json:"result,omitempty"`
+	Error  *JSONRPCError `json:"error,omitempty"`
+}
+
+// operandDefaults are the identity elements used for a missing b when the
+// server runs with LenientDefaults
+var operandDefaults = map[string]map[string]interface{}{
+	"math.add":      {"b": 0},
+	"math.subtract": {"b": 0},
+	"math.multiply": {"b": 1},
+	"math.divide":   {"b": 1},
+}
+
+// statefulMethods lists the Calculator methods that modify the memory register
+var statefulMethods = map[string]bool{
+	"MemStore": true,
+	"MemClear": true,
+	"MemAdd":   true,
+	"MemUndo":  true,
+}
+
+// MemoryParams represents parameters for memory register operations
+type MemoryParams struct {
+	A float64 `json:"a"`
+}
+
+// LogarithmParams represents parameters for a logarithm; Base defaults to e
+type LogarithmParams struct {
+	Value float64  `json:"value"`
+	Base  *float64 `json:"base,omitempty"`
+}
+
+// LogParams represents parameters for the logMessage notification
+type LogParams struct {
+	Message string `json:"message"`
+}
+
+// Add performs addition
+func (c *Calculator) Add(params CalculatorParams) (float64, error) {
+	result := params.A + params.B
+	c.logger.Debug("calculation", "operation", "add", "a", params.A, "b", params.B, "result", result)
+	return result, nil
+}
+
+// IntAdd adds two int64 values, reporting overflow instead of wrapping or losing precision
+func (c *Calculator) IntAdd(params IntParams) (int64, error) {
+	if (params.B > 0 && params.A > math.MaxInt64-params.B) || (params.B < 0 && params.A < math.MinInt64-params.B) {
+		return 0, &JSONRPCError{
+			Code:    -32000, // Application error
+			Message: "Integer overflow",
+			Data:    fmt.Sprintf("%d + %d exceeds the int64 range", params.A, params.B),
+		}
+	}
+
+	result := params.A + params.B
+	c.logger.Debug("calculation", "operation", "intAdd", "a", params.A, "b", params.B, "result", result)
+	return result, nil
+}
+
+// Subtract performs subtraction
+func (c *Calculator) Subtract(params CalculatorParams) (float64, error) {
+	result := params.A - params.B
+	c.logger.Debug("calculation", "operation", "subtract", "a", params.A, "b", params.B, "result", result)
+	return result, nil
+}
+
+// Multiply performs multiplication
+func (c *Calculator) Multiply(params CalculatorParams) (float64, error) {
+	result := params.A * params.B
+	c.logger.Debug("calculation", "operation", "multiply", "a", params.A, "b", params.B, "result", result)
+	return result, nil
+}
+
+// Divide performs division with error handling for divide by zero
+func (c *Calculator) Divide(params CalculatorParams) (float64, error) {
+	if params.B == 0 {
+		return 0, &JSONRPCError{
+			Code:    -32000, // Application error
+			Message: "Division by zero",
+			Data:    fmt.Sprintf("Cannot divide %f by zero", params.A),
+		}
+	}
+
+	result := params.A / params.B
+	c.logger.Debug("calculation", "operation", "divide", "a", params.A, "b", params.B, "result", result)
+	return result, nil
+}
+
+// divModConvention documents how DivMod rounds, reported in getInfo and its errors
+const divModConvention = "divmod truncates toward zero: quotient = trunc(a/b), remainder = a - b*quotient takes the sign of a"
+
+// Percent computes A percent of B
+func (c *Calculator) Percent(params CalculatorParams) (float64, error) {
+	result := params.A / 100 * params.B
+	c.logger.Debug("calculation", "operation", "percent", "a", params.A, "b", params.B, "result", result)
+	return result, nil
+}
+
+// PercentChange computes the percentage change from A to B
+func (c *Calculator) PercentChange(params CalculatorParams) (float64, error) {
+	if params.A == 0 {
+		return 0, &JSONRPCError{
+			Code:    -32000, // Application error
+			Message: "Undefined percent change",
+			Data:    fmt.Sprintf("Cannot compute percent change from %f", params.A),
+		}
+	}
+
+	result := (params.B - params.A) / params.A * 100
+	c.logger.Debug("calculation", "operation", "percentChange", "a", params.A, "b", params.B, "result", result)
+	return result, nil
+}
+
+// DivMod returns the truncated quotient and remainder of A / B
+func (c *Calculator) DivMod(params CalculatorParams) (map[string]float64, error) {
+	if params.B == 0 {
+		return nil, &JSONRPCError{
+			Code:    -32000, // Application error
+			Message: "Division by zero",
+			Data:    fmt.Sprintf("Cannot divide %f by zero (%s)", params.A, divModConvention),
+		}
+	}
+
+	quotient := math.Trunc(params.A / params.B)
+	remainder := math.Mod(params.A, params.B)
+	c.logger.Debug("calculation", "operation", "divmod", "a", params.A, "b", params.B, "quotient", quotient, "remainder", remainder)
+	return map[string]float64{"quotient": quotient, "remainder": remainder}, nil
+}
+
+// Modulo computes the remainder of A / B with error handling for modulo by zero
+// The result takes the sign of A, matching math.Mod
+func (c *Calculator) Modulo(params CalculatorParams) (float64, error) {
+	if params.B == 0 {
+		return 0, &JSONRPCError{
+			Code:    -32000, // Application error
+			Message: "Modulo by zero",
+			Data:    fmt.Sprintf("Cannot compute %f modulo zero", params.A),
+		}
+	}
+
+	result := math.Mod(params.A, params.B)
+	c.logger.Debug("calculation", "operation", "modulo", "a", params.A, "b", params.B, "result", result)
+	return result, nil
+}
+
+// Power raises A to the power of B with errors for non-finite results
+func (c *Calculator) Power(params CalculatorParams) (float64, error) {
+	if params.A < 0 && params.B != math.Trunc(params.B) {
+		return 0, &JSONRPCError{
+			Code:    -32000, // Application error
+			Message: "Invalid power operation",
+			Data:    fmt.Sprintf("Cannot raise negative base %f to non-integer exponent %f", params.A, params.B),
+		}
+	}
+
+	// math.Pow defines 0^0 as 1, which we keep
+	result := math.Pow(params.A, params.B)
+	if math.IsInf(result, 0) || math.IsNaN(result) {
+		return 0, &JSONRPCError{
+			Code:    -32000, // Application error
+			Message: "Invalid power operation",
+			Data:    fmt.Sprintf("%f ^ %f is not a finite number (note: 0^0 is defined as 1)", params.A, params.B),
+		}
+	}
+
+	c.logger.Debug("calculation", "operation", "power", "a", params.A, "b", params.B, "result", result)
+	return result, nil
+}
+
+// Sqrt computes the square root with error handling for negative input
+func (c *Calculator) Sqrt(params UnaryParams) (float64, error) {
+	if params.Value < 0 {
+		return 0, &JSONRPCError{
+			Code:    -32000, // Application error
+			Message: "Square root of negative number",
+			Data:    fmt.Sprintf("Cannot take square root of %f", params.Value),
+		}
+	}
+
+	result := math.Sqrt(params.Value)
+	c.logger.Debug("calculation", "operation", "sqrt", "value", params.Value, "result", result)
+	return result, nil
+}
+
+// checkFinite rejects NaN and ±Inf results, which encoding/json cannot marshal
+func checkFinite(v float64) error {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return &JSONRPCError{
+			Code:    -32000, // Application error
+			Message: "Result is not a finite number",
+			Data:    fmt.Sprintf("The computation produced %v", v),
+		}
+	}
+	return nil
+}
+
+// nanError reports a NaN operand as an application error
+func nanError(operation string) error {
+	return &JSONRPCError{
+		Code:    -32000, // Application error
+		Message: "Invalid operand",
+		Data:    fmt.Sprintf("Cannot compute %s of NaN", operation),
+	}
+}
+
+// Abs computes the absolute value
+func (c *Calculator) Abs(params UnaryParams) (float64, error) {
+	if math.IsNaN(params.Value) {
+		return 0, nanError("abs")
+	}
+
+	result := math.Abs(params.Value)
+	c.logger.Debug("calculation", "operation", "abs", "value", params.Value, "result", result)
+	return result, nil
+}
+
+// Floor rounds down to the nearest integer
+func (c *Calculator) Floor(params UnaryParams) (float64, error) {
+	if math.IsNaN(params.Value) {
+		return 0, nanError("floor")
+	}
+
+	result := math.Floor(params.Value)
+	c.logger.Debug("calculation", "operation", "floor", "value", params.Value, "result", result)
+	return result, nil
+}
+
+// Ceil rounds up to the nearest integer
+func (c *Calculator) Ceil(params UnaryParams) (float64, error) {
+	if math.IsNaN(params.Value) {
+		return 0, nanError("ceil")
+	}
+
+	result := math.Ceil(params.Value)
+	c.logger.Debug("calculation", "operation", "ceil", "value", params.Value, "result", result)
+	return result, nil
+}
+
+// Round rounds half away from zero to the given number of decimal digits
+func (c *Calculator) Round(params RoundParams) (float64, error) {
+	if math.IsNaN(params.Value) {
+		return 0, nanError("round")
+	}
+
+	scale := math.Pow(10, float64(params.Digits))
+	var result float64
+	switch {
+	case scale == 0:
+		// Rounding to a power of ten too large for a float64 leaves nothing
+		result = 0
+	case math.IsInf(scale, 0) || math.IsInf(params.Value*scale, 0):
+		// Scaling overflowed: the value already has fewer digits than requested
+		result = params.Value
+	default:
+		result = math.Round(params.Value*scale) / scale
+	}
+
+	c.logger.Debug("calculation", "operation", "round", "value", params.Value, "digits", params.Digits, "result", result)
+	return result, nil
+}
+
+// Sin computes the sine of an angle
+func (c *Calculator) Sin(params AngleParams) (float64, error) {
+	result := math.Sin(params.radians())
+	c.logger.Debug("calculation", "operation", "sin", "value", params.Value, "degrees", params.Degrees, "result", result)
+	return result, nil
+}
+
+// Cos computes the cosine of an angle
+func (c *Calculator) Cos(params AngleParams) (float64, error) {
+	result := math.Cos(params.radians())
+	c.logger.Debug("calculation", "operation", "cos", "value", params.Value, "degrees", params.Degrees, "result", result)
+	return result, nil
+}
+
+// Tan computes the tangent of an angle with error handling near odd multiples of pi/2
+func (c *Calculator) Tan(params AngleParams) (float64, error) {
+	result := math.Tan(params.radians())
+	if math.Abs(result) > 1e15 {
+		return 0, &JSONRPCError{
+			Code:    -32000, // Application error
+			Message: "Numerically unstable result",
+			Data:    fmt.Sprintf("tan(%f) is undefined or too close to an asymptote", params.Value),
+		}
+	}
+
+	c.logger.Debug("calculation", "operation", "tan", "value", params.Value, "degrees", params.Degrees, "result", result)
+	return result, nil
+}
+
+// trigDomainError reports an inverse trig input outside [-1, 1]
+func trigDomainError(operation string, value float64) error {
+	return &JSONRPCError{
+		Code:    -32000, // Application error
+		Message: "Domain error",
+		Data:    fmt.Sprintf("%s is only defined for values in [-1, 1], got %g", operation, value),
+	}
+}
+
+// Asin computes the arc sine in radians
+func (c *Calculator) Asin(params UnaryParams) (float64, error) {
+	if params.Value < -1 || params.Value > 1 {
+		return 0, trigDomainError("asin", params.Value)
+	}
+
+	result := math.Asin(params.Value)
+	c.logger.Debug("calculation", "operation", "asin", "value", params.Value, "result", result)
+	return result, nil
+}
+
+// Acos computes the arc cosine in radians
+func (c *Calculator) Acos(params UnaryParams) (float64, error) {
+	if params.Value < -1 || params.Value > 1 {
+		return 0, trigDomainError("acos", params.Value)
+	}
+
+	result := math.Acos(params.Value)
+	c.logger.Debug("calculation", "operation", "acos", "value", params.Value, "result", result)
+	return result, nil
+}
+
+// Atan computes the arc tangent in radians
+func (c *Calculator) Atan(params UnaryParams) (float64, error) {
+	result := math.Atan(params.Value)
+	c.logger.Debug("calculation", "operation", "atan", "value", params.Value, "result", result)
+	return result, nil
+}
+
+// Atan2 computes the angle in radians of the point (x, y) = (B, A)
+func (c *Calculator) Atan2(params CalculatorParams) (float64, error) {
+	result := math.Atan2(params.A, params.B)
+	c.logger.Debug("calculation", "operation", "atan2", "y", params.A, "x", params.B, "result", result)
+	return result, nil
+}
+
+// Sinh computes the hyperbolic sine
+func (c *Calculator) Sinh(params UnaryParams) (float64, error) {
+	result := math.Sinh(params.Value)
+	c.logger.Debug("calculation", "operation", "sinh", "value", params.Value, "result", result)
+	return result, nil
+}
+
+// Cosh computes the hyperbolic cosine
+func (c *Calculator) Cosh(params UnaryParams) (float64, error) {
+	result := math.Cosh(params.Value)
+	c.logger.Debug("calculation", "operation", "cosh", "value", params.Value, "result", result)
+	return result, nil
+}
+
+// Tanh computes the hyperbolic tangent
+func (c *Calculator) Tanh(params UnaryParams) (float64, error) {
+	result := math.Tanh(params.Value)
+	c.logger.Debug("calculation", "operation", "tanh", "value", params.Value, "result", result)
+	return result, nil
+}
+
+// GCD computes the greatest common divisor of two integers
+// gcd(0, 0) is defined as 0, since every integer divides 0
+func (c *Calculator) GCD(params CalculatorParams) (float64, error) {
+	if err := validateInteger("a", params.A); err != nil {
+		return 0, err
+	}
+	if err := validateInteger("b", params.B); err != nil {
+		return 0, err
+	}
+
+	result := gcd(params.A, params.B)
+	c.logger.Debug("calculation", "operation", "gcd", "a", params.A, "b", params.B, "result", result)
+	return result, nil
+}
+
+// LCM computes the least common multiple of two integers (0 if either is 0)
+func (c *Calculator) LCM(params CalculatorParams) (float64, error) {
+	if err := validateInteger("a", params.A); err != nil {
+		return 0, err
+	}
+	if err := validateInteger("b", params.B); err != nil {
+		return 0, err
+	}
+
+	result := 0.0
+	if params.A != 0 && params.B != 0 {
+		// Divide before multiplying so the intermediate never exceeds the result
+		result = math.Abs(params.A) / gcd(params.A, params.B) * math.Abs(params.B)
+	}
+	if result > maxSafeInteger {
+		return 0, &JSONRPCError{
+			Code:    -32000, // Application error
+			Message: "Result too large",
+			Data:    fmt.Sprintf("lcm(%g, %g) exceeds 2^53 and cannot be represented exactly", params.A, params.B),
+		}
+	}
+
+	c.logger.Debug("calculation", "operation", "lcm", "a", params.A, "b", params.B, "result", result)
+	return result, nil
+}
+
+// Factorial computes n!
+func (c *Calculator) Factorial(params FactorialParams) (float64, error) {
+	if params.N > maxFactorial {
+		return 0, &JSONRPCError{
+			Code:    -32000, // Application error
+			Message: "Result too large",
+			Data:    fmt.Sprintf("%g! exceeds the float64 range (max n is %d)", params.N, maxFactorial),
+		}
+	}
+
+	result := 1.0
+	for i := 2.0; i <= params.N; i++ {
+		result *= i
+	}
+	c.logger.Debug("calculation", "operation", "factorial", "n", params.N, "result", result)
+	return result, nil
+}
+
+// Combinations computes n choose r
+func (c *Calculator) Combinations(params CombinationsParams) (float64, error) {
+	if params.N > maxCombinationsN {
+		return 0, &JSONRPCError{
+			Code:    -32000, // Application error
+			Message: "Result too large",
+			Data:    fmt.Sprintf("C(%g, %g) exceeds the limit (max n is %d)", params.N, params.R, maxCombinationsN),
+		}
+	}
+
+	// Multiply and divide incrementally using the smaller of r and n-r so
+	// intermediate values stay close to the final result. The result at least
+	// doubles each step, so the loop stops at Inf after about a thousand
+	r := math.Min(params.R, params.N-params.R)
+	result := 1.0
+	for i := 1.0; i <= r && !math.IsInf(result, 0); i++ {
+		result = result * (params.N - r + i) / i
+	}
+	result = math.Round(result)
+
+	if math.IsInf(result, 0) {
+		return 0, &JSONRPCError{
+			Code:    -32000, // Application error
+			Message: "Result too large",
+			Data:    fmt.Sprintf("C(%g, %g) exceeds the float64 range", params.N, params.R),
+		}
+	}
+
+	c.logger.Debug("calculation", "operation", "combinations", "n", params.N, "r", params.R, "result", result)
+	return result, nil
+}
+
+// Chain applies each op to the running value, starting from Start
+// A failing step is reported with its index in ops
+func (c *Calculator) Chain(params ChainParams) (float64, error) {
+	result := params.Start
+	for i, step := range params.Ops {
+		value, err := chainOperations[step.Op](c, CalculatorParams{A: result, B: *step.Value})
+		if err != nil {
+			jsonrpcErr, ok := err.(*JSONRPCError)
+			if !ok {
+				return 0, err
+			}
+			return 0, &JSONRPCError{
+				Code:    jsonrpcErr.Code,
+				Message: jsonrpcErr.Message,
+				Data: map[string]interface{}{
+					"step":   i,
+					"op":     step.Op,
+					"detail": jsonrpcErr.Data,
+				},
+			}
+		}
+		result = value
+	}
+
+	c.logger.Debug("calculation", "operation", "chain", "start", params.Start, "steps", len(params.Ops), "result", result)
+	return result, nil
+}
+
+// Eval evaluates an arithmetic expression with + - * /, parentheses, unary minus,
+// the variables given in params.Vars and calls such as sqrt(16) or max(1, 2, 3)
+func (c *Calculator) Eval(params EvalParams) (float64, error) {
+	functions := c.exprFunctions()
+	for _, name := range varNames(params.Vars) {
+		if _, ok := functions(name); ok {
+			return 0, invalidParams("vars."+name, reasonInvalid, "identifier", fmt.Sprintf("Variable name %q is reserved for the function of that name", name))
+		}
+	}
+
+	result, err := evalExpression(params.Expr, params.Vars, functions, c.exprDepth())
+	if err != nil {
+		return 0, err
+	}
+
+	c.logger.Debug("calculation", "operation", "eval", "expr", params.Expr, "result", result)
+	return result, nil
+}
+
+// EvalBatch evaluates each expression independently; a failing expression
+// gets its error in place instead of failing the call
+func (c *Calculator) EvalBatch(params EvalBatchParams) ([]EvalResult, error) {
+	results := make([]EvalResult, len(params.Exprs))
+	failed := 0
+	for i, expr := range params.Exprs {
+		value, err := evalExpression(expr, nil, c.exprFunctions(), c.exprDepth())
+		if err == nil {
+			// NaN and ±Inf can't be encoded as JSON, so fail just this slot
+			err = checkFinite(value)
+		}
+		if err != nil {
+			var rpcErr *JSONRPCError
+			if !errors.As(err, &rpcErr) {
+				rpcErr = &JSONRPCError{Code: InternalError, Message: "Internal error"}
+			}
+			results[i].Error = rpcErr
+			failed++
+			continue
+		}
+		results[i].Result = &value
+	}
+
+	c.logger.Debug("calculation", "operation", "evalBatch", "exprs", len(params.Exprs), "failed", failed)
+	return results, nil
+}
+
+// Sum adds all values (0 for an empty list)
+func (c *Calculator) Sum(params VariadicParams) (float64, error) {
+	result := 0.0
+	for _, v := range params.Values {
+		result += v
+	}
+	c.logger.Debug("calculation", "operation", "sum", "values", params.Values, "result", result)
+	return result, nil
+}
+
+// Product multiplies all values (1 for an empty list)
+func (c *Calculator) Product(params VariadicParams) (float64, error) {
+	result := 1.0
+	for _, v := range params.Values {
+		result *= v
+	}
+	c.logger.Debug("calculation", "operation", "product", "values", params.Values, "result", result)
+	return result, nil
+}
+
+// nonEmptyValues rejects an empty list for operations undefined on nothing
+func nonEmptyValues(operation string, values []float64) error {
+	if len(values) == 0 {
+		return invalidParams("values", reasonRequired, "non-empty array of numbers", fmt.Sprintf("Parameter 'values' must contain at least one number for %s", operation))
+	}
+	return nil
+}
+
+// Min returns the smallest value
+func (c *Calculator) Min(params VariadicParams) (float64, error) {
+	if err := nonEmptyValues("min", params.Values); err != nil {
+		return 0, err
+	}
+
+	result := params.Values[0]
+	for _, v := range params.Values[1:] {
+		result = math.Min(result, v)
+	}
+	c.logger.Debug("calculation", "operation", "min", "values", params.Values, "result", result)
+	return result, nil
+}
+
+// Max returns the largest value
+func (c *Calculator) Max(params VariadicParams) (float64, error) {
+	if err := nonEmptyValues("max", params.Values); err != nil {
+		return 0, err
+	}
+
+	result := params.Values[0]
+	for _, v := range params.Values[1:] {
+		result = math.Max(result, v)
+	}
+	c.logger.Debug("calculation", "operation", "max", "values", params.Values, "result", result)
+	return result, nil
+}
+
+// Average returns the arithmetic mean of the values
+func (c *Calculator) Average(params VariadicParams) (float64, error) {
+	if err := nonEmptyValues("average", params.Values); err != nil {
+		return 0, err
+	}
+
+	sum := 0.0
+	for _, v := range params.Values {
+		sum += v
+	}
+	result := sum / float64(len(params.Values))
+	c.logger.Debug("calculation", "operation", "average", "values", params.Values, "result", result)
+	return result, nil
+}
+
+// Clamp bounds value to [min, max]; values on a bound are returned unchanged
+func (c *Calculator) Clamp(params ClampParams) (float64, error) {
+	result := math.Min(math.Max(params.Value, params.Min), params.Max)
+	c.logger.Debug("calculation", "operation", "clamp", "value", params.Value, "min", params.Min, "max", params.Max, "result", result)
+	return result, nil
+}
+
+// MemStore replaces the memory register with A
+func (c *Calculator) MemStore(params MemoryParams) (float64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.saveUndo()
+	c.memory = params.A
+	c.logger.Debug("memory updated", "operation", "memStore", "memory", c.memory)
+	return c.memory, nil
+}
+
+// MemRecall returns the value in the memory register
+func (c *Calculator) MemRecall() (float64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.logger.Debug("memory recalled", "memory", c.memory)
+	return c.memory, nil
+}
+
+// MemClear resets the memory register to 0
+func (c *Calculator) MemClear() (float64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.saveUndo()
+	c.memory = 0
+	c.logger.Debug("memory updated", "operation", "memClear", "memory", c.memory)
+	return c.memory, nil
+}
+
+// MemAdd adds A to the memory register and returns the new total
+func (c *Calculator) MemAdd(params MemoryParams) (float64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.saveUndo()
+	c.memory += params.A
+	c.logger.Debug("memory updated", "operation", "memAdd", "a", params.A, "memory", c.memory)
+	return c.memory, nil
+}
+
+// MemUndo reverts the memory register to its value before the last change
+func (c *Calculator) MemUndo() (float64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.undo) == 0 {
+		return 0, &JSONRPCError{
+			Code:    -32000, // Application error
+			Message: "Nothing to undo",
+			Data:    "the memory register has no earlier changes to revert",
+		}
+	}
+	c.memory = c.undo[len(c.undo)-1]
+	c.undo = c.undo[:len(c.undo)-1]
+	c.logger.Debug("memory updated", "operation", "memUndo", "memory", c.memory)
+	return c.memory, nil
+}
+
+// reset zeroes the memory register and drops the undo stack, returning the
+// previous memory value and how many undo entries were dropped
+func (c *Calculator) reset() (float64, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	memory, undone := c.memory, len(c.undo)
+	c.memory = 0
+	c.undo = nil
+	return memory, undone
+}
+
+// saveUndo records the current memory value before a change, dropping the
+// oldest entry beyond maxUndo. Callers must hold c.mu
+func (c *Calculator) saveUndo() {
+	if len(c.undo) == maxUndo {
+		c.undo = append(c.undo[:0], c.undo[1:]...)
+	}
+	c.undo = append(c.undo, c.memory)
+}
+
+// Log computes the logarithm of value in the given base (natural log by default)
+func (c *Calculator) Log(params LogarithmParams) (float64, error) {
+	if params.Value <= 0 {
+		return 0, &JSONRPCError{
+			Code:    -32000, // Application error
+			Message: "Logarithm of non-positive number",
+			Data:    fmt.Sprintf("Cannot take the logarithm of %g", params.Value),
+		}
+	}
+
+	base := math.E
+	if params.Base != nil {
+		base = *params.Base
+	}
+	if base <= 0 || base == 1 {
+		return 0, &JSONRPCError{
+			Code:    -32000, // Application error
+			Message: "Invalid logarithm base",
+			Data:    fmt.Sprintf("Base must be positive and not equal to 1, got %g", base),
+		}
+	}
+
+	result := math.Log(params.Value) / math.Log(base)
+	c.logger.Debug("calculation", "operation", "log", "value", params.Value, "base", base, "result", result)
+	return result, nil
+}
+
+// LogMessage handles notification messages (no response)
+func (c *Calculator) LogMessage(params LogParams) {
+	c.logger.Info("client log", "message", params.Message)
+	// Note: This is a notification, so we don't return anything
+}
+
+// GetInfo returns information about the calculator (demonstrates method without params)
+// The method lists are supplied by the server's method registry
+func (c *Calculator) GetInfo(catalog MethodCatalog) (map[string]interface{}, error) {
+	info := map[string]interface{}{
+		"name":          serviceName,
+		"version":       serviceVersion,
+		"methods":       catalog.Methods,
+		"notifications": catalog.Notifications,
+		"aliases":       catalog.Aliases,
+		"namespaces":    catalog.Namespaces,
+		"description":   "A simple calculator implementing JSON-RPC 2.0",
+		"conventions":   map[string]string{"divmod": divModConvention},
+	}
+
+	c.logger.Debug("info requested")
+	return info, nil
+}
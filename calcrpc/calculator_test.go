@@ -0,0 +1,182 @@
+package calcrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestCalculator returns a calculator that discards its logs
+func newTestCalculator() *Calculator {
+	return &Calculator{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+}
+
+func TestRound(t *testing.T) {
+	c := newTestCalculator()
+	tests := []struct {
+		value  float64
+		digits int
+		want   float64
+	}{
+		{2.345, 2, 2.35},
+		{1234, -2, 1200},
+		{123, -400, 0},
+		{-123, -400, 0},
+		{0, 400, 0},
+		{1.5, 400, 1.5},
+		{1e300, 100, 1e300},
+	}
+	for _, tt := range tests {
+		got, err := c.Round(RoundParams{Value: tt.value, Digits: tt.digits})
+		if err != nil || got != tt.want {
+			t.Errorf("Round(%v, %d) = %v, %v, want %v", tt.value, tt.digits, got, err, tt.want)
+		}
+	}
+}
+
+func TestPercent(t *testing.T) {
+	c := newTestCalculator()
+	tests := []struct {
+		a, b, want float64
+	}{
+		{10, 200, 20},
+		{-10, 200, -20},
+		{150, 40, 60},
+	}
+	for _, tt := range tests {
+		got, err := c.Percent(CalculatorParams{A: tt.a, B: tt.b})
+		if err != nil || got != tt.want {
+			t.Errorf("Percent(%v, %v) = %v, %v, want %v", tt.a, tt.b, got, err, tt.want)
+		}
+	}
+}
+
+func TestPercentChange(t *testing.T) {
+	c := newTestCalculator()
+	tests := []struct {
+		a, b, want float64
+	}{
+		{100, 150, 50},
+		{200, 150, -25},
+		{80, 0, -100},
+		{-50, -25, -50},
+	}
+	for _, tt := range tests {
+		got, err := c.PercentChange(CalculatorParams{A: tt.a, B: tt.b})
+		if err != nil || got != tt.want {
+			t.Errorf("PercentChange(%v, %v) = %v, %v, want %v", tt.a, tt.b, got, err, tt.want)
+		}
+	}
+
+	_, err := c.PercentChange(CalculatorParams{A: 0, B: 5})
+	rpcErr, ok := err.(*JSONRPCError)
+	if !ok || rpcErr.Code != -32000 {
+		t.Fatalf("PercentChange(0, 5) err = %v, want a -32000 application error", err)
+	}
+	if data, _ := rpcErr.Data.(string); !strings.Contains(data, "0.000000") {
+		t.Errorf("data = %v, want it to echo A", rpcErr.Data)
+	}
+}
+
+func TestCombinationsHugeOperands(t *testing.T) {
+	c := newTestCalculator()
+	tests := []struct {
+		name string
+		n, r float64
+	}{
+		{"beyond exact integers", 1e16, 5e15},
+		{"overflowing result", 1 << 53, 1 << 52},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done := make(chan error, 1)
+			go func() {
+				_, err := c.Combinations(CombinationsParams{N: tt.n, R: tt.r})
+				done <- err
+			}()
+			select {
+			case err := <-done:
+				if rpcErr, ok := err.(*JSONRPCError); !ok || rpcErr.Code != -32000 {
+					t.Fatalf("err = %v, want a -32000 application error", err)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Combinations did not return")
+			}
+		})
+	}
+}
+
+func TestClamp(t *testing.T) {
+	s := newTestServer()
+	tests := []struct {
+		name          string
+		value, lo, hi float64
+		want          float64
+	}{
+		{"below", 1, 5, 9, 5},
+		{"equal to min", 5, 5, 9, 5},
+		{"inside", 7, 5, 9, 7},
+		{"equal to max", 9, 5, 9, 9},
+		{"above", 12, 5, 9, 9},
+		{"empty range", 3, 4, 4, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := fmt.Sprintf(`{"jsonrpc":"2.0","method":"clamp","params":{"value":%v,"min":%v,"max":%v},"id":1}`, tt.value, tt.lo, tt.hi)
+			var result float64
+			decodeInto(t, callResponse(t, s, body), &result)
+			if result != tt.want {
+				t.Errorf("clamp(%v, %v, %v) = %v, want %v", tt.value, tt.lo, tt.hi, result, tt.want)
+			}
+		})
+	}
+
+	if code := callError(t, s, `{"jsonrpc":"2.0","method":"clamp","params":{"value":5,"min":9,"max":1},"id":2}`); code != InvalidParams {
+		t.Errorf("clamp with min > max failed with %d, want InvalidParams", code)
+	}
+}
+
+func TestEvalBatchNonFiniteResult(t *testing.T) {
+	s := newTestServer()
+	response := callResponse(t, s, `{"jsonrpc":"2.0","method":"evalBatch","params":{"exprs":["1e308*10","1+1"]},"id":1}`)
+
+	var results []EvalResult
+	decodeInto(t, response, &results)
+	if len(results) != 2 || results[0].Error == nil || results[0].Error.Code != -32000 || results[1].Result == nil || *results[1].Result != 2 {
+		t.Fatalf("results = %s, want an error then 2", response.Result)
+	}
+
+	// The history entry must still be encodable
+	if code := callError(t, s, `{"jsonrpc":"2.0","method":"history","id":2}`); code != 0 {
+		t.Fatalf("history failed with %d", code)
+	}
+}
+
+func TestEvalVarsReservedForRegisteredFunctions(t *testing.T) {
+	s := newTestServer()
+	s.RegisterMethodWithInfo("double", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return nil, nil
+	}, MethodInfo{Params: reflect.TypeOf(UnaryParams{})})
+
+	tests := []struct {
+		name string
+		want int
+	}{
+		{"x", 0},
+		{"sqrt", InvalidParams},
+		{"double", InvalidParams},
+		{"add", 0}, // takes two operands, so it can't be called in an expression
+	}
+	for _, tt := range tests {
+		body := fmt.Sprintf(`{"jsonrpc":"2.0","method":"eval","params":{"expr":"1","vars":{%q:1}},"id":1}`, tt.name)
+		if code := callError(t, s, body); code != tt.want {
+			t.Errorf("eval with variable %q failed with %d, want %d", tt.name, code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package calcrpc
+
+import (
+	"sort"
+)
+
+// Capabilities is the result of the capabilities method: the protocol features
+// and limits of this server as configured, so clients can feature-detect at runtime
+type Capabilities struct {
+	Batch               bool     `json:"batch"`
+	Notifications       bool     `json:"notifications"`
+	MaxBatchSize        int      `json:"maxBatchSize"` // 0 when unlimited
+	RequestTimeoutMs    int64    `json:"requestTimeoutMs"`
+	MaxRequestTimeoutMs int64    `json:"maxRequestTimeoutMs"` // 0 when client overrides are uncapped
+	MaxExprDepth        int      `json:"maxExprDepth"`
+	Transports          []string `json:"transports"`   // e.g. "http", "ws", "tcp", "stdio"
+	AuthRequired        bool     `json:"authRequired"` // the HTTP and WebSocket endpoints require an API key
+	Extensions          []string `json:"extensions"`   // non-standard features, see capabilities
+}
+
+// addTransport records that the server is reachable over the named transports,
+// for the capabilities method
+func (s *JSONRPCServer) addTransport(authRequired bool, names ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.transports == nil {
+		s.transports = make(map[string]bool)
+	}
+	for _, name := range names {
+		s.transports[name] = true
+	}
+	s.authRequired = s.authRequired || authRequired
+}
+
+// capabilities describes the server's current configuration
+func (s *JSONRPCServer) capabilities() Capabilities {
+	s.mu.RLock()
+	transports := make([]string, 0, len(s.transports))
+	for name := range s.transports {
+		transports = append(transports, name)
+	}
+	authRequired, webSocket := s.authRequired, s.transports["ws"]
+	s.mu.RUnlock()
+	sort.Strings(transports)
+
+	maxExprDepth := s.MaxExprDepth
+	if maxExprDepth < 1 {
+		maxExprDepth = DefaultMaxExprDepth
+	}
+
+	// Request extensions beyond JSON-RPC 2.0, named after the params member or
+	// method that enables them
+	extensions := []string{"includeTiming", "validate", "verbose", "format", "rpc.discover"}
+	if s.IdempotencyTTL > 0 {
+		extensions = append(extensions, "idempotencyKey")
+	}
+	if webSocket {
+		extensions = append(extensions, "subscribe")
+	}
+
+	return Capabilities{
+		Batch:               true,
+		Notifications:       true,
+		MaxBatchSize:        s.MaxBatchSize,
+		RequestTimeoutMs:    s.RequestTimeout.Milliseconds(),
+		MaxRequestTimeoutMs: s.MaxRequestTimeout.Milliseconds(),
+		MaxExprDepth:        maxExprDepth,
+		Transports:          transports,
+		AuthRequired:        authRequired,
+		Extensions:          extensions,
+	}
+}
@@ -0,0 +1,278 @@
+package calcrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// Client calls a JSON-RPC calculator server over HTTP
+// Error responses are returned as *JSONRPCError, so callers can inspect Code and Data.
+// Request ids come from a per-client counter, so callers never pick them and
+// concurrent calls on one Client never share an id
+type Client struct {
+	// URL is the JSON-RPC endpoint, e.g. "http://localhost:8090/"
+	URL string
+
+	// APIKey is sent as a bearer token when set (see CALC_API_KEY)
+	APIKey string
+
+	// HTTPClient sends the requests; nil uses http.DefaultClient
+	HTTPClient *http.Client
+
+	// Retry decides whether calls that hit a TransportError are sent again;
+	// nil never retries. JSON-RPC error responses are never retried
+	Retry RetryPolicy
+
+	nextID atomic.Int64 // last id used; see newID
+}
+
+// NewClient creates a client for the JSON-RPC endpoint at url
+func NewClient(url string) *Client {
+	return &Client{URL: url}
+}
+
+// BatchCall is one call of a batch sent with Client.Batch
+// Result is decoded into like Call's result; Error is set when that call failed
+type BatchCall struct {
+	Method string
+	Params interface{}
+	Result interface{}
+	Error  error
+}
+
+// clientResponse is a response as read by the client, with the result left
+// raw so it can be decoded into the caller's type
+type clientResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *JSONRPCError   `json:"error"`
+	ID     *int64          `json:"id"`
+}
+
+// newID returns the next request id
+func (c *Client) newID() int64 {
+	return c.nextID.Add(1)
+}
+
+// Call invokes method with params and decodes the result into result,
+// which may be nil to discard it
+func (c *Client) Call(method string, params interface{}, result interface{}) error {
+	return c.CallContext(context.Background(), method, params, result)
+}
+
+// CallContext is Call with a context for cancellation and deadlines
+func (c *Client) CallContext(ctx context.Context, method string, params interface{}, result interface{}) error {
+	request := JSONRPCRequest{JSONRPC: "2.0", Method: method, Params: params, ID: c.newID()}
+
+	var response clientResponse
+	err := c.withRetry(ctx, []string{method}, func() error {
+		return c.post(ctx, request, &response)
+	})
+	if err != nil {
+		return err
+	}
+	return decodeResult(response, result)
+}
+
+// Notify sends method as a notification: no id, and no result to wait for.
+// The server doesn't answer notifications, so only transport failures and
+// rejections before dispatch (e.g. a missing API key) are reported
+func (c *Client) Notify(method string, params interface{}) error {
+	return c.NotifyContext(context.Background(), method, params)
+}
+
+// NotifyContext is Notify with a context for cancellation and deadlines
+func (c *Client) NotifyContext(ctx context.Context, method string, params interface{}) error {
+	notification := JSONRPCNotification{JSONRPC: "2.0", Method: method, Params: params}
+
+	var response clientResponse
+	err := c.withRetry(ctx, []string{method}, func() error {
+		return c.post(ctx, notification, &response)
+	})
+	if err != nil {
+		return err
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	return nil
+}
+
+// Batch sends every call in one batch request and fills in each call's Result
+// or Error, matching responses to calls by id since the server may answer in
+// any order. The returned error covers only the batch as a whole (transport
+// failures or a malformed reply)
+func (c *Client) Batch(calls []BatchCall) error {
+	return c.BatchContext(context.Background(), calls)
+}
+
+// BatchContext is Batch with a context for cancellation and deadlines
+func (c *Client) BatchContext(ctx context.Context, calls []BatchCall) error {
+	if len(calls) == 0 {
+		return nil
+	}
+
+	requests := make([]JSONRPCRequest, len(calls))
+	methods := make([]string, len(calls))
+	indexes := make(map[int64]int, len(calls))
+	for i, call := range calls {
+		id := c.newID()
+		requests[i] = JSONRPCRequest{JSONRPC: "2.0", Method: call.Method, Params: call.Params, ID: id}
+		methods[i] = call.Method
+		indexes[id] = i
+	}
+
+	// A rejected batch (e.g. too large) comes back as a single error object
+	var raw json.RawMessage
+	err := c.withRetry(ctx, methods, func() error {
+		return c.post(ctx, requests, &raw)
+	})
+	if err != nil {
+		return err
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")) {
+		var response clientResponse
+		if err := json.Unmarshal(raw, &response); err != nil {
+			return fmt.Errorf("calcrpc: invalid batch response: %w", err)
+		}
+		if response.Error != nil {
+			return response.Error
+		}
+		return fmt.Errorf("calcrpc: batch answered with a single response")
+	}
+
+	var responses []clientResponse
+	if err := json.Unmarshal(raw, &responses); err != nil {
+		return fmt.Errorf("calcrpc: invalid batch response: %w", err)
+	}
+
+	answered := make([]bool, len(calls))
+	for _, response := range responses {
+		if response.ID == nil {
+			continue
+		}
+		i, ok := indexes[*response.ID]
+		if !ok {
+			continue
+		}
+		answered[i] = true
+		calls[i].Error = decodeResult(response, calls[i].Result)
+	}
+	for i := range calls {
+		if !answered[i] {
+			calls[i].Error = fmt.Errorf("calcrpc: no response for %s in batch", calls[i].Method)
+		}
+	}
+	return nil
+}
+
+// post sends payload and decodes the JSON reply into response
+// Non-200 replies still carry a JSON-RPC error (see CALC_HTTP_ERROR_STATUS and
+// CALC_API_KEY), so the body is decoded whenever it is JSON. Failures before a
+// JSON reply arrives are returned as *TransportError
+func (c *Client) post(ctx context.Context, payload interface{}, response interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return &TransportError{Err: err}
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return &TransportError{Err: err}
+	}
+	// Notifications are answered with 204 and no body
+	if len(data) == 0 && resp.StatusCode < 300 {
+		return nil
+	}
+	if err := json.Unmarshal(data, response); err != nil {
+		err = fmt.Errorf("HTTP %d with invalid JSON-RPC response: %w", resp.StatusCode, err)
+		if resp.StatusCode >= 500 {
+			// A proxy or load balancer answered instead of the calculator
+			return &TransportError{StatusCode: resp.StatusCode, Err: err}
+		}
+		return fmt.Errorf("calcrpc: %w", err)
+	}
+	return nil
+}
+
+// decodeResult returns the response's error, or decodes its result into result
+func decodeResult(response clientResponse, result interface{}) error {
+	if response.Error != nil {
+		return response.Error
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		return fmt.Errorf("calcrpc: decoding result: %w", err)
+	}
+	return nil
+}
+
+// callNumber calls a method that returns a single number
+func (c *Client) callNumber(method string, params interface{}) (float64, error) {
+	var result float64
+	err := c.Call(method, params, &result)
+	return result, err
+}
+
+// Add returns a + b
+func (c *Client) Add(a, b float64) (float64, error) {
+	return c.callNumber("math.add", CalculatorParams{A: a, B: b})
+}
+
+// Subtract returns a - b
+func (c *Client) Subtract(a, b float64) (float64, error) {
+	return c.callNumber("math.subtract", CalculatorParams{A: a, B: b})
+}
+
+// Multiply returns a * b
+func (c *Client) Multiply(a, b float64) (float64, error) {
+	return c.callNumber("math.multiply", CalculatorParams{A: a, B: b})
+}
+
+// Divide returns a / b; dividing by zero returns the server's application error
+func (c *Client) Divide(a, b float64) (float64, error) {
+	return c.callNumber("math.divide", CalculatorParams{A: a, B: b})
+}
+
+// Power returns a raised to the power of b
+func (c *Client) Power(a, b float64) (float64, error) {
+	return c.callNumber("math.power", CalculatorParams{A: a, B: b})
+}
+
+// Sum returns the sum of values
+func (c *Client) Sum(values ...float64) (float64, error) {
+	if values == nil {
+		values = []float64{} // an empty list sums to 0, a missing one is Invalid params
+	}
+	return c.callNumber("stats.sum", VariadicParams{Values: values})
+}
+
+// Eval evaluates an arithmetic expression such as "2 * (3 + 4)"
+func (c *Client) Eval(expr string) (float64, error) {
+	return c.callNumber("math.eval", EvalParams{Expr: expr})
+}
@@ -0,0 +1,138 @@
+package calcrpc
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// preciseDivisionDigits bounds the decimal places of a quotient that has no
+// finite decimal expansion (e.g. 1/3); the last digit is rounded
+const preciseDivisionDigits = 34
+
+// maxDecimalExponent bounds exponents like "1e400" so a short string can't
+// expand into an enormous exact number
+const maxDecimalExponent = 1000
+
+// decimalPattern matches a plain decimal string such as "-12.50" or "1e-3"
+var decimalPattern = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)(?:[eE]([+-]?\d+))?$`)
+
+// DecimalParams represents parameters for the precise operations
+// Operands are decimal strings so they never pass through float64
+type DecimalParams struct {
+	A string `json:"a"`
+	B string `json:"b"`
+}
+
+// Validate ensures both operands are decimal strings
+func (p *DecimalParams) Validate() error {
+	if _, err := parseDecimal("a", p.A); err != nil {
+		return err
+	}
+	_, err := parseDecimal("b", p.B)
+	return err
+}
+
+// operands returns A and B as exact rationals
+func (p DecimalParams) operands() (*big.Rat, *big.Rat) {
+	a, _ := parseDecimal("a", p.A)
+	b, _ := parseDecimal("b", p.B)
+	return a, b
+}
+
+// parseDecimal parses a decimal string parameter exactly
+func parseDecimal(name string, value string) (*big.Rat, error) {
+	match := decimalPattern.FindStringSubmatch(value)
+	if match == nil {
+		return nil, invalidParams(name, reasonInvalid, "decimal string", fmt.Sprintf("Parameter '%s' must be a decimal string like \"0.1\", got %q", name, value))
+	}
+	if exponent, err := strconv.Atoi(match[2]); match[2] != "" && (err != nil || exponent > maxDecimalExponent || exponent < -maxDecimalExponent) {
+		return nil, invalidParams(name, reasonRange, fmt.Sprintf("exponent between -%d and %d", maxDecimalExponent, maxDecimalExponent), fmt.Sprintf("Parameter '%s' exponent must be between -%d and %d", name, maxDecimalExponent, maxDecimalExponent))
+	}
+
+	r, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return nil, invalidParams(name, reasonInvalid, "decimal string", fmt.Sprintf("Parameter '%s' must be a decimal string like \"0.1\", got %q", name, value))
+	}
+	return r, nil
+}
+
+// formatDecimal renders r as a plain decimal string without trailing zeros
+// Exact when r has a finite decimal expansion, otherwise rounded to preciseDivisionDigits
+func formatDecimal(r *big.Rat) string {
+	s := r.FloatString(decimalDigits(r))
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
+
+// decimalDigits returns how many decimal places represent r exactly, which is
+// possible only when its denominator has no prime factors other than 2 and 5
+func decimalDigits(r *big.Rat) int {
+	denom := new(big.Int).Set(r.Denom())
+	twos, fives := 0, 0
+	two, five := big.NewInt(2), big.NewInt(5)
+	mod := new(big.Int)
+	for {
+		if q, m := new(big.Int).QuoRem(denom, two, mod); m.Sign() == 0 {
+			denom, twos = q, twos+1
+			continue
+		}
+		if q, m := new(big.Int).QuoRem(denom, five, mod); m.Sign() == 0 {
+			denom, fives = q, fives+1
+			continue
+		}
+		break
+	}
+
+	if denom.Cmp(big.NewInt(1)) != 0 {
+		return preciseDivisionDigits
+	}
+	return max(twos, fives)
+}
+
+// PreciseAdd adds decimal strings exactly
+func (c *Calculator) PreciseAdd(params DecimalParams) (string, error) {
+	a, b := params.operands()
+	result := formatDecimal(new(big.Rat).Add(a, b))
+	c.logger.Debug("calculation", "operation", "preciseAdd", "a", params.A, "b", params.B, "result", result)
+	return result, nil
+}
+
+// PreciseSubtract subtracts decimal strings exactly
+func (c *Calculator) PreciseSubtract(params DecimalParams) (string, error) {
+	a, b := params.operands()
+	result := formatDecimal(new(big.Rat).Sub(a, b))
+	c.logger.Debug("calculation", "operation", "preciseSubtract", "a", params.A, "b", params.B, "result", result)
+	return result, nil
+}
+
+// PreciseMultiply multiplies decimal strings exactly
+func (c *Calculator) PreciseMultiply(params DecimalParams) (string, error) {
+	a, b := params.operands()
+	result := formatDecimal(new(big.Rat).Mul(a, b))
+	c.logger.Debug("calculation", "operation", "preciseMultiply", "a", params.A, "b", params.B, "result", result)
+	return result, nil
+}
+
+// PreciseDivide divides decimal strings, exact unless the quotient doesn't terminate
+func (c *Calculator) PreciseDivide(params DecimalParams) (string, error) {
+	a, b := params.operands()
+	if b.Sign() == 0 {
+		return "", &JSONRPCError{
+			Code:    -32000, // Application error
+			Message: "Division by zero",
+			Data:    fmt.Sprintf("Cannot divide %s by zero", params.A),
+		}
+	}
+
+	result := formatDecimal(new(big.Rat).Quo(a, b))
+	c.logger.Debug("calculation", "operation", "preciseDivide", "a", params.A, "b", params.B, "result", result)
+	return result, nil
+}
@@ -0,0 +1,433 @@
+package calcrpc
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxExprDepth is the nesting limit of eval expressions unless
+// MaxExprDepth is changed
+const DefaultMaxExprDepth = 64
+
+// exprFunc computes a function called in an expression from its evaluated arguments
+type exprFunc func(args []float64) (float64, error)
+
+// exprFuncs resolves the name of a function called in an expression
+type exprFuncs func(name string) (exprFunc, bool)
+
+// evalExpression parses and evaluates an arithmetic expression such as "sqrt(x) * (4 - 1)"
+// Supported: numbers, variables from vars, calls of functions resolved by funcs,
+// + - * /, parentheses and unary minus (or plus)
+//
+// Grammar:
+//
+//	expr    = term { ("+" | "-") term }
+//	term    = unary { ("*" | "/") unary }
+//	unary   = ("-" | "+") unary | primary
+//	primary = number | identifier | call | "(" expr ")"
+//	call    = name "(" [ expr { "," expr } ] ")"
+//
+// Parentheses, function calls and unary signs nest at most maxDepth deep
+// (DefaultMaxExprDepth if maxDepth < 1), so hostile input can't exhaust the stack
+func evalExpression(input string, vars map[string]float64, funcs exprFuncs, maxDepth int) (float64, error) {
+	if maxDepth < 1 {
+		maxDepth = DefaultMaxExprDepth
+	}
+	p := &exprParser{input: input, vars: vars, funcs: funcs, maxDepth: maxDepth}
+	if p.peek() == 0 {
+		return 0, &JSONRPCError{
+			Code:    -32000, // Application error
+			Message: "Invalid expression",
+			Data:    "expression is empty",
+		}
+	}
+
+	value, err := p.parseExpr()
+	if err != nil {
+		return 0, err
+	}
+	if !p.atEnd() {
+		return 0, p.errorf(p.pos, "unexpected %q", p.input[p.pos])
+	}
+	return value, nil
+}
+
+// exprParser is a recursive-descent parser that evaluates as it parses
+type exprParser struct {
+	input    string
+	pos      int                // byte offset of the next unread character
+	vars     map[string]float64 // variable values by name
+	funcs    exprFuncs          // resolves function calls; nil allows none
+	depth    int                // current nesting of parentheses, calls and unary signs
+	maxDepth int
+}
+
+// errorf builds a syntax error reported at byte offset pos (shown 1-based)
+func (p *exprParser) errorf(pos int, format string, args ...interface{}) error {
+	return &JSONRPCError{
+		Code:    -32000, // Application error
+		Message: "Invalid expression",
+		Data:    fmt.Sprintf("%s at position %d", fmt.Sprintf(format, args...), pos+1),
+	}
+}
+
+// nest enters one more level of nesting at pos, failing beyond maxDepth
+// Every successful nest must be paired with p.depth--
+func (p *exprParser) nest(pos int) error {
+	if p.depth >= p.maxDepth {
+		return p.errorf(pos, "expression too deeply nested (limit %d)", p.maxDepth)
+	}
+	p.depth++
+	return nil
+}
+
+func (p *exprParser) atEnd() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *exprParser) skipSpaces() {
+	for !p.atEnd() && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t' || p.input[p.pos] == '\n' || p.input[p.pos] == '\r') {
+		p.pos++
+	}
+}
+
+// peek returns the next non-space character, or 0 at the end of input
+func (p *exprParser) peek() byte {
+	p.skipSpaces()
+	if p.atEnd() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *exprParser) parseExpr() (float64, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		op := p.peek()
+		if op != '+' && op != '-' {
+			return left, nil
+		}
+		p.pos++
+
+		right, err := p.parseTerm()
+		if err != nil {
+			return 0, err
+		}
+		if op == '+' {
+			left += right
+		} else {
+			left -= right
+		}
+	}
+}
+
+func (p *exprParser) parseTerm() (float64, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		op := p.peek()
+		if op != '*' && op != '/' {
+			return left, nil
+		}
+		opPos := p.pos
+		p.pos++
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return 0, err
+		}
+		if op == '*' {
+			left *= right
+			continue
+		}
+		if right == 0 {
+			return 0, &JSONRPCError{
+				Code:    -32000, // Application error
+				Message: "Division by zero",
+				Data:    fmt.Sprintf("Cannot divide %g by zero at position %d", left, opPos+1),
+			}
+		}
+		left /= right
+	}
+}
+
+func (p *exprParser) parseUnary() (float64, error) {
+	op := p.peek()
+	if op != '-' && op != '+' {
+		return p.parsePrimary()
+	}
+	if err := p.nest(p.pos); err != nil {
+		return 0, err
+	}
+	defer func() { p.depth-- }()
+
+	p.pos++
+	value, err := p.parseUnary()
+	if op == '-' {
+		value = -value
+	}
+	return value, err
+}
+
+func (p *exprParser) parsePrimary() (float64, error) {
+	c := p.peek()
+	switch {
+	case c == 0:
+		return 0, p.errorf(p.pos, "unexpected end of expression")
+	case c == '(':
+		open := p.pos
+		if err := p.nest(open); err != nil {
+			return 0, err
+		}
+		defer func() { p.depth-- }()
+
+		p.pos++
+		value, err := p.parseExpr()
+		if err != nil {
+			return 0, err
+		}
+		if p.peek() != ')' {
+			return 0, p.errorf(open, "unclosed '('")
+		}
+		p.pos++
+		return value, nil
+	case isDigit(c) || c == '.':
+		return p.parseNumber()
+	case isIdentStart(c):
+		return p.parseIdentifier()
+	}
+	return 0, p.errorf(p.pos, "unexpected %q", c)
+}
+
+// parseNumber reads a decimal literal with an optional exponent, e.g. 3, 0.5, 1e-3
+func (p *exprParser) parseNumber() (float64, error) {
+	start := p.pos
+	for !p.atEnd() && (isDigit(p.input[p.pos]) || p.input[p.pos] == '.') {
+		p.pos++
+	}
+	if !p.atEnd() && (p.input[p.pos] == 'e' || p.input[p.pos] == 'E') {
+		p.pos++
+		if !p.atEnd() && (p.input[p.pos] == '+' || p.input[p.pos] == '-') {
+			p.pos++
+		}
+		for !p.atEnd() && isDigit(p.input[p.pos]) {
+			p.pos++
+		}
+	}
+
+	value, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+	if err != nil {
+		return 0, p.errorf(start, "invalid number %q", p.input[start:p.pos])
+	}
+	return value, nil
+}
+
+// parseIdentifier reads a name and returns the value of that variable, or of
+// the function call when the name is followed by "(". Function names may be
+// namespaced, e.g. math.sqrt
+func (p *exprParser) parseIdentifier() (float64, error) {
+	start := p.pos
+	for !p.atEnd() && (isIdentStart(p.input[p.pos]) || isDigit(p.input[p.pos]) || p.input[p.pos] == '.') {
+		p.pos++
+	}
+
+	name := p.input[start:p.pos]
+	if p.peek() == '(' {
+		return p.parseCall(name, start)
+	}
+	value, ok := p.vars[name]
+	if !ok {
+		return 0, &JSONRPCError{
+			Code:    -32000, // Application error
+			Message: "Undefined variable",
+			Data:    fmt.Sprintf("variable %q is not defined at position %d", name, start+1),
+		}
+	}
+	return value, nil
+}
+
+// parseCall evaluates the arguments of a call of name at start, then the function
+// Errors from the function are reported with its name and position
+func (p *exprParser) parseCall(name string, start int) (float64, error) {
+	var fn exprFunc
+	ok := false
+	if p.funcs != nil {
+		fn, ok = p.funcs(name)
+	}
+	if !ok {
+		return 0, &JSONRPCError{
+			Code:    -32000, // Application error
+			Message: "Undefined function",
+			Data:    fmt.Sprintf("function %q is not defined at position %d", name, start+1),
+		}
+	}
+
+	open := p.pos
+	if err := p.nest(start); err != nil {
+		return 0, err
+	}
+	defer func() { p.depth-- }()
+
+	p.pos++
+	var args []float64
+	if p.peek() != ')' {
+		for {
+			arg, err := p.parseExpr()
+			if err != nil {
+				return 0, err
+			}
+			args = append(args, arg)
+			if p.peek() != ',' {
+				break
+			}
+			p.pos++
+		}
+	}
+	if p.peek() != ')' {
+		return 0, p.errorf(open, "unclosed '('")
+	}
+	p.pos++
+
+	value, err := fn(args)
+	if err != nil {
+		return 0, functionError(name, start, err)
+	}
+	return value, nil
+}
+
+// functionError adds the function name and its position to an error returned
+// by a function call. Application errors keep their message; anything else
+// (e.g. invalid arguments) is reported as an invalid expression
+func functionError(name string, pos int, err error) error {
+	rpcErr, ok := err.(*JSONRPCError)
+	if !ok {
+		return err
+	}
+
+	detail := rpcErr.Message
+	switch data := rpcErr.Data.(type) {
+	case string:
+		detail = data
+	case InvalidParamsDetail:
+		detail = data.Message
+	}
+
+	message := rpcErr.Message
+	if rpcErr.Code != -32000 {
+		message = "Invalid expression"
+	}
+	return &JSONRPCError{
+		Code:    -32000, // Application error
+		Message: message,
+		Data:    fmt.Sprintf("%s in %s() at position %d", detail, name, pos+1),
+	}
+}
+
+// exprFunctionParams builds the params of a function taking one value or a
+// list of values from the arguments of its call
+func exprFunctionParams(paramType reflect.Type, args []float64) (interface{}, error) {
+	if paramType == reflect.TypeOf(VariadicParams{}) {
+		return VariadicParams{Values: append([]float64{}, args...)}, nil
+	}
+	if len(args) != 1 {
+		return nil, &JSONRPCError{
+			Code:    -32000, // Application error
+			Message: "Invalid expression",
+			Data:    fmt.Sprintf("expected 1 argument, got %d", len(args)),
+		}
+	}
+	if paramType == reflect.TypeOf(AngleParams{}) {
+		return AngleParams{Value: args[0]}, nil
+	}
+	return UnaryParams{Value: args[0]}, nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isIdentifier reports whether name is a valid variable name: a letter or
+// underscore followed by letters, digits and underscores
+func isIdentifier(name string) bool {
+	if name == "" || !isIdentStart(name[0]) {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if !isIdentStart(name[i]) && !isDigit(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// exprParamTypes are the params of methods callable as expression functions
+var exprParamTypes = map[reflect.Type]bool{
+	reflect.TypeOf(UnaryParams{}):    true,
+	reflect.TypeOf(AngleParams{}):    true,
+	reflect.TypeOf(VariadicParams{}): true,
+}
+
+// calculatorFunctions are the Calculator methods taking a single value or a
+// list of values, by their lowerCamel method name: the expression functions of
+// a Calculator outside a server
+var calculatorFunctions = func() map[string]reflect.Method {
+	functions := make(map[string]reflect.Method)
+	calculatorType := reflect.TypeOf(&Calculator{})
+	for i := 0; i < calculatorType.NumMethod(); i++ {
+		method := calculatorType.Method(i)
+		if method.Type.NumIn() == 2 && exprParamTypes[method.Type.In(1)] {
+			functions[strings.ToLower(method.Name[:1])+method.Name[1:]] = method
+		}
+	}
+	return functions
+}()
+
+// exprDepth returns the nesting limit for c's expressions
+func (c *Calculator) exprDepth() int {
+	if c.maxExprDepth != nil {
+		return c.maxExprDepth()
+	}
+	return DefaultMaxExprDepth
+}
+
+// exprFunctions returns the resolver for function calls in c's expressions:
+// the server's method registry when c belongs to one, else c's own methods
+func (c *Calculator) exprFunctions() exprFuncs {
+	if c.functions != nil {
+		return c.functions
+	}
+	return c.methodFunction
+}
+
+// methodFunction resolves name to one of c's methods taking a single value or
+// a list of values
+func (c *Calculator) methodFunction(name string) (exprFunc, bool) {
+	method, ok := calculatorFunctions[name]
+	if !ok {
+		return nil, false
+	}
+	return func(args []float64) (float64, error) {
+		params, err := exprFunctionParams(method.Type.In(1), args)
+		if err != nil {
+			return 0, err
+		}
+		result, err := callResults(method.Func.Call([]reflect.Value{reflect.ValueOf(c), reflect.ValueOf(params)}))
+		if err != nil {
+			return 0, err
+		}
+		return result.(float64), nil
+	}, true
+}
@@ -0,0 +1,43 @@
+package calcrpc
+
+import (
+	"strings"
+	"testing"
+)
+
+// nested returns expr wrapped in depth pairs of parentheses
+func nested(expr string, depth int) string {
+	return strings.Repeat("(", depth) + expr + strings.Repeat(")", depth)
+}
+
+func TestExpressionDepthLimit(t *testing.T) {
+	s := newTestServer()
+	eval := func(expr string) testResponse {
+		return callResponse(t, s, `{"jsonrpc":"2.0","method":"eval","params":{"expr":"`+expr+`"},"id":1}`)
+	}
+
+	var result float64
+	decodeInto(t, eval(nested("1+1", DefaultMaxExprDepth-1)), &result)
+	if result != 2 {
+		t.Errorf("eval within the limit = %v, want 2", result)
+	}
+
+	for _, expr := range []string{nested("1", 1000), strings.Repeat("-", 1000) + "1"} {
+		response := eval(expr)
+		if response.Error == nil || response.Error.Code != -32000 {
+			t.Fatalf("error = %v, want a -32000 application error", response.Error)
+		}
+		if data, _ := response.Error.Data.(string); !strings.HasPrefix(data, "expression too deeply nested") {
+			t.Errorf("data = %v, want \"expression too deeply nested\"", response.Error.Data)
+		}
+	}
+
+	s.MaxExprDepth = 4
+	if response := eval(nested("1", 5)); response.Error == nil || response.Error.Code != -32000 {
+		t.Errorf("error with MaxExprDepth 4 = %v, want a -32000 application error", response.Error)
+	}
+	decodeInto(t, eval(nested("1", 3)), &result)
+	if result != 1 {
+		t.Errorf("eval with MaxExprDepth 4 = %v, want 1", result)
+	}
+}
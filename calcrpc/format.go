@@ -0,0 +1,112 @@
+package calcrpc
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// NumberFormat selects locale-style formatting of numeric results, requested
+// with a "format" member in named params, e.g. {"decimalSep": ",", "thousandSep": "."}
+type NumberFormat struct {
+	DecimalSep  string `json:"decimalSep"`
+	ThousandSep string `json:"thousandSep,omitempty"` // no grouping when empty
+}
+
+// Validate ensures the separators are single characters that differ
+func (f *NumberFormat) Validate() error {
+	if utf8.RuneCountInString(f.DecimalSep) != 1 || utf8.RuneCountInString(f.ThousandSep) > 1 || f.DecimalSep == f.ThousandSep {
+		return invalidParams("format", reasonInvalid, `{"decimalSep": one character, "thousandSep": a different character (optional)}`, "Parameter 'format' needs a one-character 'decimalSep' and an optional, different one-character 'thousandSep'")
+	}
+	return nil
+}
+
+// splitFormatOption removes an optional "format" member from named params
+func splitFormatOption(params json.RawMessage) (json.RawMessage, *NumberFormat, error) {
+	if len(params) == 0 || params[0] != '{' {
+		return params, nil, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(params, &fields); err != nil {
+		// Leave malformed params for decodeParams to report
+		return params, nil, nil
+	}
+	raw, ok := fields["format"]
+	if !ok {
+		return params, nil, nil
+	}
+
+	var format NumberFormat
+	if err := decodeParams(raw, &format, `{"decimalSep": string, "thousandSep": string (optional)}`); err != nil {
+		return nil, nil, err
+	}
+	if err := format.Validate(); err != nil {
+		return nil, nil, err
+	}
+	delete(fields, "format")
+
+	stripped, err := json.Marshal(fields)
+	if err != nil {
+		return nil, nil, err
+	}
+	return stripped, &format, nil
+}
+
+// formatResult renders the numbers of a result as strings using format
+// Non-numeric results are returned unchanged
+func formatResult(result interface{}, format NumberFormat) interface{} {
+	switch v := result.(type) {
+	case float64:
+		return formatNumber(v, format)
+	case int64:
+		return formatDigits(strconv.FormatInt(v, 10), format)
+	case map[string]float64:
+		formatted := make(map[string]string, len(v))
+		for key, f := range v {
+			formatted[key] = formatNumber(f, format)
+		}
+		return formatted
+	case ResultEnvelope:
+		v.Value = formatResult(v.Value, format)
+		return v
+	}
+	return result
+}
+
+// formatNumber writes v with the given separators, grouping the integer part in
+// thousands. Very large or small magnitudes use exponent notation without grouping
+func formatNumber(v float64, format NumberFormat) string {
+	if abs := math.Abs(v); abs != 0 && (abs >= 1e21 || abs < 1e-6) {
+		return strings.Replace(strconv.FormatFloat(v, 'e', -1, 64), ".", format.DecimalSep, 1)
+	}
+
+	return formatDigits(strconv.FormatFloat(v, 'f', -1, 64), format)
+}
+
+// formatDigits applies format to a plain decimal number such as "-1234.5"
+func formatDigits(text string, format NumberFormat) string {
+	sign := ""
+	if strings.HasPrefix(text, "-") {
+		sign, text = "-", text[1:]
+	}
+	integer, fraction, hasFraction := strings.Cut(text, ".")
+
+	if format.ThousandSep != "" {
+		var grouped strings.Builder
+		for i, digit := range integer {
+			if i > 0 && (len(integer)-i)%3 == 0 {
+				grouped.WriteString(format.ThousandSep)
+			}
+			grouped.WriteRune(digit)
+		}
+		integer = grouped.String()
+	}
+
+	if hasFraction {
+		return sign + integer + format.DecimalSep + fraction
+	}
+	return sign + integer
+}
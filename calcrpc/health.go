@@ -0,0 +1,75 @@
+package calcrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// HealthStatus is the body served by /health
+type HealthStatus struct {
+	Status  string `json:"status"` // "healthy" or "unhealthy"
+	Service string `json:"service"`
+	Healthy bool   `json:"healthy"`
+	Detail  string `json:"detail,omitempty"` // why the self-check failed
+}
+
+// selfCheck verifies the server can dispatch a trivial calculation end to end
+// The call bypasses history and metrics so health probes don't show up there
+func (s *JSONRPCServer) selfCheck(ctx context.Context) error {
+	if len(s.methodNames()) == 0 {
+		return fmt.Errorf("method registry is empty")
+	}
+
+	result, err := s.callMethod(ctx, "math.add", []float64{1, 2})
+	if err != nil {
+		return fmt.Errorf("dispatching math.add failed: %w", err)
+	}
+	if result != 3.0 {
+		return fmt.Errorf("math.add(1, 2) returned %v, want 3", result)
+	}
+	return nil
+}
+
+// serveLiveness returns a handler that only reports the process is up
+func serveLiveness() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status": "alive", "service": "` + serviceName + `"}`))
+	}
+}
+
+// serveReadiness returns a handler answering 503 until the server is Ready
+func serveReadiness(rpcServer *JSONRPCServer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status, code := "ready", http.StatusOK
+		if !rpcServer.Ready() {
+			status, code = "not ready", http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		w.Write([]byte(`{"status": "` + status + `", "service": "` + serviceName + `"}`))
+	}
+}
+
+// serveHealth returns a handler reporting the self-check result
+// Failures are served with 503 so orchestrators can restart the instance
+func serveHealth(rpcServer *JSONRPCServer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := HealthStatus{Status: "healthy", Service: serviceName, Healthy: true}
+		code := http.StatusOK
+		if err := rpcServer.selfCheck(r.Context()); err != nil {
+			rpcServer.logger.Error("health check failed", "error", err)
+			status = HealthStatus{Status: "unhealthy", Service: serviceName, Healthy: false, Detail: err.Error()}
+			code = http.StatusServiceUnavailable
+		}
+
+		body, _ := json.Marshal(status)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		w.Write(body)
+	}
+}
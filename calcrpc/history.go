@@ -0,0 +1,60 @@
+package calcrpc
+
+import (
+	"sync"
+	"time"
+)
+
+// historySize is the number of operations kept in the history buffer
+const historySize = 100
+
+// HistoryEntry records one successful method call
+type HistoryEntry struct {
+	Method    string      `json:"method"`
+	Params    interface{} `json:"params,omitempty"`
+	Result    interface{} `json:"result,omitempty"`
+	Type      string      `json:"type"` // "request" or "notification"
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// History is a fixed-size ring buffer of recent successful calls
+type History struct {
+	mu      sync.Mutex
+	entries []HistoryEntry
+	next    int // index the next entry is written to once the buffer is full
+}
+
+// Add records an entry, evicting the oldest one when the buffer is full
+func (h *History) Add(entry HistoryEntry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.entries) < historySize {
+		h.entries = append(h.entries, entry)
+		return
+	}
+	h.entries[h.next] = entry
+	h.next = (h.next + 1) % historySize
+}
+
+// Entries returns the recorded calls, oldest first
+func (h *History) Entries() []HistoryEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entries := make([]HistoryEntry, 0, len(h.entries))
+	entries = append(entries, h.entries[h.next:]...)
+	entries = append(entries, h.entries[:h.next]...)
+	return entries
+}
+
+// Clear removes all entries and returns how many were removed
+func (h *History) Clear() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cleared := len(h.entries)
+	h.entries = nil
+	h.next = 0
+	return cleared
+}
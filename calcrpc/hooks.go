@@ -0,0 +1,32 @@
+package calcrpc
+
+import (
+	"time"
+)
+
+// CallHook observes a dispatched call once it has finished: the method as
+// requested, its raw params, and the result or the error (nil on success).
+// err is the full *JSONRPCError, before VerboseErrors hides Internal error
+// details from the client. Hooks run synchronously on the request's goroutine,
+// so slow work such as shipping audit records should be handed off
+type CallHook func(method string, params interface{}, result interface{}, err error, dur time.Duration)
+
+// AddCallHook registers hook to run after every request and notification;
+// hooks run in registration order
+func (s *JSONRPCServer) AddCallHook(hook CallHook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, hook)
+}
+
+// runCallHooks passes a finished call to every registered hook
+func (s *JSONRPCServer) runCallHooks(method string, params interface{}, result interface{}, err error, start time.Time) {
+	s.mu.RLock()
+	hooks := s.hooks
+	s.mu.RUnlock()
+
+	dur := time.Since(start)
+	for _, hook := range hooks {
+		hook(method, params, result, err, dur)
+	}
+}
@@ -0,0 +1,458 @@
+package calcrpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// DefaultMaxBodyBytes caps the HTTP request body unless MaxBodyBytes is set
+const DefaultMaxBodyBytes = 1 << 20 // 1 MB
+
+// Defaults used by HTTPTransport timeouts left at zero. The write timeout
+// outlasts DefaultMaxRequestTimeout so a slow call can still be answered
+const (
+	DefaultHTTPReadHeaderTimeout = 5 * time.Second
+	DefaultHTTPReadTimeout       = 30 * time.Second
+	DefaultHTTPWriteTimeout      = DefaultMaxRequestTimeout + 15*time.Second
+	DefaultHTTPIdleTimeout       = 120 * time.Second
+)
+
+// HTTPTransport serves JSON-RPC over HTTP at "/" (POST, and GET for read-only
+// methods) and over WebSocket at "/ws", next to /metrics and the health probes.
+// HTTP/2 is served alongside HTTP/1.1: negotiated via ALPN over TLS, and as
+// cleartext h2c for clients that speak it with prior knowledge
+type HTTPTransport struct {
+	// Addr is the listen address, e.g. ":8090"
+	Addr string
+
+	// CertFile and KeyFile serve HTTPS with this certificate and key when both are set
+	CertFile string
+	KeyFile  string
+
+	// APIKey is the bearer token required on "/" and "/ws"; empty disables the check
+	APIKey string
+
+	// CORSOrigins lists the browser origins allowed to call "/" and "/ws"; "*" allows any
+	CORSOrigins []string
+
+	// MaxBodyBytes caps the size of a POST body and of a WebSocket frame;
+	// 0 uses DefaultMaxBodyBytes
+	MaxBodyBytes int64
+
+	// HTTPErrorStatus maps single error responses to a matching HTTP status
+	// instead of 200 (see httpStatusForResponse)
+	HTTPErrorStatus bool
+
+	// Connection timeouts guarding against slow clients (see http.Server);
+	// 0 uses the DefaultHTTP*Timeout of the same name. WebSocket connections
+	// on "/ws" are exempt once upgraded
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+}
+
+// Serve listens on Addr until ctx is cancelled
+func (t *HTTPTransport) Serve(ctx context.Context, rpcServer *JSONRPCServer) error {
+	server := t.httpServer(rpcServer)
+	stop := context.AfterFunc(ctx, func() { server.Close() })
+	defer stop()
+
+	useTLS := t.CertFile != "" && t.KeyFile != ""
+	rpcServer.logger.Info("JSON-RPC HTTP endpoint listening", "addr", t.Addr, "tls", useTLS)
+	var err error
+	if useTLS {
+		err = server.ListenAndServeTLS(t.CertFile, t.KeyFile)
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// httpServer returns the server for Serve, speaking HTTP/1.1 and HTTP/2 with
+// the configured timeouts.
+// Cleartext HTTP/2 comes from http.Server.Protocols rather than
+// golang.org/x/net/http2/h2c, which is deprecated in its favour; unlike the h2c
+// handler it only accepts prior knowledge, not an "Upgrade: h2c" request
+func (t *HTTPTransport) httpServer(rpcServer *JSONRPCServer) *http.Server {
+	server := &http.Server{
+		Addr:              t.Addr,
+		Handler:           t.Handler(rpcServer),
+		Protocols:         new(http.Protocols),
+		ReadHeaderTimeout: orDefault(t.ReadHeaderTimeout, DefaultHTTPReadHeaderTimeout),
+		ReadTimeout:       orDefault(t.ReadTimeout, DefaultHTTPReadTimeout),
+		WriteTimeout:      orDefault(t.WriteTimeout, DefaultHTTPWriteTimeout),
+		IdleTimeout:       orDefault(t.IdleTimeout, DefaultHTTPIdleTimeout),
+	}
+	server.Protocols.SetHTTP1(true)
+	server.Protocols.SetHTTP2(true)
+	server.Protocols.SetUnencryptedHTTP2(true)
+	return server
+}
+
+// Handler returns the HTTP routes of the transport, for mounting in another server
+func (t *HTTPTransport) Handler(rpcServer *JSONRPCServer) http.Handler {
+	rpcServer.addTransport(t.APIKey != "", "http", "ws")
+	mux := http.NewServeMux()
+
+	// JSON-RPC over HTTP
+	mux.Handle("/", requireAPIKey(t.APIKey, t.CORSOrigins, t.serveJSONRPC(rpcServer)))
+
+	// WebSocket endpoint sharing the same JSON-RPC dispatch
+	mux.Handle("/ws", requireAPIKey(t.APIKey, t.CORSOrigins, serveWebSocket(rpcServer, t.CORSOrigins, t.maxBodyBytes())))
+
+	// Prometheus metrics endpoint
+	mux.Handle("/metrics", promhttp.Handler())
+
+	// Health check endpoint, backed by an internal dispatch self-check
+	mux.HandleFunc("/health", serveHealth(rpcServer))
+
+	// Kubernetes-style liveness and readiness probes
+	mux.HandleFunc("/livez", serveLiveness())
+	mux.HandleFunc("/readyz", serveReadiness(rpcServer))
+
+	return mux
+}
+
+// maxBodyBytes returns MaxBodyBytes, or DefaultMaxBodyBytes when it is unset
+func (t *HTTPTransport) maxBodyBytes() int64 {
+	if t.MaxBodyBytes > 0 {
+		return t.MaxBodyBytes
+	}
+	return DefaultMaxBodyBytes
+}
+
+// orDefault returns d, or fallback when d is not positive
+func orDefault(d, fallback time.Duration) time.Duration {
+	if d > 0 {
+		return d
+	}
+	return fallback
+}
+
+// serveJSONRPC returns the handler for JSON-RPC requests on "/"
+func (t *HTTPTransport) serveJSONRPC(rpcServer *JSONRPCServer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Set CORS headers, echoing the Origin only when it is allowlisted
+		if origin := allowedOrigin(t.CORSOrigins, r.Header.Get("Origin")); origin != "" {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-Timeout-Ms, Idempotency-Key")
+		}
+		w.Header().Add("Vary", "Origin")
+
+		// Handle OPTIONS request for CORS preflight
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		var body []byte
+		if r.Method == "GET" {
+			// Read-only methods may also be called with query parameters
+			var jsonrpcErr *JSONRPCError
+			body, jsonrpcErr = rpcServer.queryRequest(r.URL.Query())
+			if jsonrpcErr != nil {
+				status := http.StatusBadRequest
+				if jsonrpcErr.Code == MethodNotFound {
+					status = http.StatusMethodNotAllowed
+				}
+				writeJSONRPCError(w, status, jsonrpcErr)
+				return
+			}
+		} else {
+			var ok bool
+			if body, ok = readPOSTBody(w, r, t.maxBodyBytes()); !ok {
+				return
+			}
+		}
+
+		// Clients may ask for a different timeout, capped at MaxRequestTimeout
+		ctx := r.Context()
+		if timeout, ok := requestTimeoutHeader(r); ok {
+			ctx = withRequestTimeout(ctx, timeout)
+		}
+
+		// Retries carrying the same Idempotency-Key get the first result back
+		if key := r.Header.Get("Idempotency-Key"); key != "" {
+			ctx = withIdempotencyKey(ctx, key)
+		}
+
+		w.Header().Add("Vary", "Accept")
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		// Process JSON-RPC request; batch responses are streamed straight to the client
+		batch := &batchResponseWriter{w: w, gzip: acceptsGzip(r)}
+		response, err := rpcServer.HandleRequestStream(ctx, body, batch)
+		if batch.started {
+			if err == nil {
+				err = batch.Close()
+			}
+			if err != nil {
+				rpcServer.logger.Error("error streaming batch response", "error", err)
+			}
+			return
+		}
+		if err != nil {
+			rpcServer.logger.Error("error processing request", "error", err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error": "Internal server error"}`))
+			return
+		}
+
+		// Set response headers
+		w.Header().Set("Content-Type", "application/json")
+
+		// Handle notifications (no response)
+		if response == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		status := http.StatusOK
+		if t.HTTPErrorStatus {
+			status = httpStatusForResponse(response)
+		}
+
+		// Shell clients asking for text/plain get a bare numeric result
+		if acceptsPlainText(r) {
+			if text, ok := plainTextResult(response); ok {
+				response = text
+				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			}
+		}
+
+		// Compress the response when the client accepts gzip
+		if acceptsGzip(r) {
+			var buf bytes.Buffer
+			gz := gzip.NewWriter(&buf)
+			gz.Write(response)
+			gz.Close()
+			response = buf.Bytes()
+			w.Header().Set("Content-Encoding", "gzip")
+		}
+
+		// Send JSON-RPC response
+		w.WriteHeader(status)
+		w.Write(response)
+	}
+}
+
+// requestTimeoutHeader parses X-Request-Timeout-Ms; a missing, malformed or
+// non-positive value reports false so the server default applies
+func requestTimeoutHeader(r *http.Request) (time.Duration, bool) {
+	ms, err := strconv.ParseInt(r.Header.Get("X-Request-Timeout-Ms"), 10, 64)
+	if err != nil || ms <= 0 || ms > math.MaxInt64/int64(time.Millisecond) {
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
+
+// httpStatusForResponse maps a single JSON-RPC error response to an HTTP status
+// Successful responses and batches (which may mix results and errors) get 200
+func httpStatusForResponse(response []byte) int {
+	var single struct {
+		Error *JSONRPCError `json:"error"`
+	}
+	if len(response) == 0 || response[0] != '{' || json.Unmarshal(response, &single) != nil || single.Error == nil {
+		return http.StatusOK
+	}
+
+	switch single.Error.Code {
+	case ParseError, InvalidRequest, InvalidParams:
+		return http.StatusBadRequest
+	case MethodNotFound:
+		return http.StatusNotFound
+	case InternalError:
+		return http.StatusInternalServerError
+	case RequestTimeout:
+		return http.StatusGatewayTimeout
+	case Unauthorized:
+		return http.StatusUnauthorized
+	default:
+		// Application errors such as division by zero: well-formed but not computable
+		return http.StatusUnprocessableEntity
+	}
+}
+
+// writeJSONRPCError writes a JSON-RPC error response (null id) with an HTTP status
+func writeJSONRPCError(w http.ResponseWriter, status int, jsonrpcErr *JSONRPCError) {
+	errorResp, _ := json.Marshal(CreateErrorResponse(jsonrpcErr, nil))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(errorResp)
+}
+
+// acceptsGzip reports whether the client listed gzip in Accept-Encoding
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		encoding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(strings.TrimSpace(encoding), "gzip") && strings.ReplaceAll(params, " ", "") != "q=0" {
+			return true
+		}
+	}
+	return false
+}
+
+// batchResponseWriter streams a batch response to the client. The status line
+// and gzip stream start with the first byte, so a batch of notifications that
+// writes nothing can still be answered with 204
+type batchResponseWriter struct {
+	w       http.ResponseWriter
+	gzip    bool
+	started bool
+	out     io.Writer
+	gz      *gzip.Writer
+}
+
+func (b *batchResponseWriter) Write(p []byte) (int, error) {
+	if !b.started {
+		b.started = true
+		b.w.Header().Set("Content-Type", "application/json")
+		b.out = b.w
+		if b.gzip {
+			b.w.Header().Set("Content-Encoding", "gzip")
+			b.gz = gzip.NewWriter(b.w)
+			b.out = b.gz
+		}
+		b.w.WriteHeader(http.StatusOK)
+	}
+	return b.out.Write(p)
+}
+
+// Close flushes the gzip stream, if one was started
+func (b *batchResponseWriter) Close() error {
+	if b.gz != nil {
+		return b.gz.Close()
+	}
+	return nil
+}
+
+// allowedOrigin returns the Access-Control-Allow-Origin value for a request's
+// Origin, or "" when the origin is not in the allowlist
+func allowedOrigin(allowed []string, origin string) string {
+	for _, candidate := range allowed {
+		if candidate == "*" {
+			return "*"
+		}
+		if origin != "" && strings.EqualFold(candidate, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// acceptsPlainText reports whether the client lists text/plain in Accept
+func acceptsPlainText(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(strings.TrimSpace(mediaType), "text/plain") && strings.ReplaceAll(params, " ", "") != "q=0" {
+			return true
+		}
+	}
+	return false
+}
+
+// plainTextResult returns the bare number of a single successful response
+// Errors, batches and non-numeric results report ok == false and stay JSON
+func plainTextResult(response []byte) (text []byte, ok bool) {
+	var single struct {
+		Result json.RawMessage `json:"result"`
+		Error  *JSONRPCError   `json:"error"`
+	}
+	if len(response) == 0 || response[0] != '{' || json.Unmarshal(response, &single) != nil || single.Error != nil {
+		return nil, false
+	}
+
+	// Strings such as decimal.* results are excluded even when they hold digits
+	var number json.Number
+	if len(single.Result) == 0 || single.Result[0] == '"' || json.Unmarshal(single.Result, &number) != nil || number == "" {
+		return nil, false
+	}
+	return []byte(number.String() + "\n"), true
+}
+
+// readPOSTBody validates a JSON-RPC POST request and reads its (possibly gzipped) body
+// On failure it writes the error response itself and returns ok == false
+func readPOSTBody(w http.ResponseWriter, r *http.Request, maxBodyBytes int64) (body []byte, ok bool) {
+	// Only accept POST requests
+	if r.Method != "POST" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		w.Write([]byte(`{"error": "Only GET and POST methods are allowed for JSON-RPC"}`))
+		return nil, false
+	}
+
+	// Check content type
+	contentType := r.Header.Get("Content-Type")
+	if !strings.Contains(contentType, "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "Content-Type must be application/json"}`))
+		return nil, false
+	}
+
+	// Read request body, capped so a huge payload can't exhaust memory
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	defer r.Body.Close()
+
+	var bodyReader io.Reader = r.Body
+	gzipped := strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip")
+	if gzipped {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			writeJSONRPCError(w, http.StatusBadRequest, &JSONRPCError{
+				Code:    ParseError,
+				Message: "Parse error",
+				Data:    "Invalid gzip request body",
+			})
+			return nil, false
+		}
+		defer gz.Close()
+		// Cap the decompressed size as well so a small gzip bomb can't expand unbounded
+		bodyReader = http.MaxBytesReader(w, gz, maxBodyBytes)
+	}
+
+	body, err := io.ReadAll(bodyReader)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		writeJSONRPCError(w, http.StatusRequestEntityTooLarge, &JSONRPCError{
+			Code:    ParseError,
+			Message: "Parse error",
+			Data:    fmt.Sprintf("Request body exceeds %d bytes", maxBytesErr.Limit),
+		})
+		return nil, false
+	}
+	if err != nil && gzipped {
+		writeJSONRPCError(w, http.StatusBadRequest, &JSONRPCError{
+			Code:    ParseError,
+			Message: "Parse error",
+			Data:    "Invalid gzip request body",
+		})
+		return nil, false
+	}
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "Cannot read request body"}`))
+		return nil, false
+	}
+
+	return body, true
+}
@@ -0,0 +1,45 @@
+package calcrpc
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHTTP2PriorKnowledge(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := (&HTTPTransport{}).httpServer(newTestServer())
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+
+	// A client that only speaks cleartext HTTP/2 sends the preface straight away
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+	defer client.CloseIdleConnections()
+
+	body := `{"jsonrpc":"2.0","method":"add","params":[1,2],"id":1}`
+	r, err := client.Post("http://"+listener.Addr().String()+"/", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Body.Close()
+
+	if r.ProtoMajor != 2 {
+		t.Errorf("protocol = %s, want HTTP/2", r.Proto)
+	}
+	var response testResponse
+	if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	var result float64
+	decodeInto(t, response, &result)
+	if result != 3 {
+		t.Errorf("add = %v, want 3", result)
+	}
+}
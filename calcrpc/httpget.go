@@ -0,0 +1,95 @@
+package calcrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// queryRequest builds a JSON-RPC request body from the query string of a GET request,
+// e.g. ?method=add&params={"a":1,"b":2}&id=1 or ?method=add&a=1&b=2&id=1
+// Query args other than method, params and id become named params; a repeated
+// arg becomes an array (?method=sum&values=1&values=2). Only read-only methods
+// may be called this way
+func (s *JSONRPCServer) queryRequest(query url.Values) ([]byte, *JSONRPCError) {
+	method := query.Get("method")
+	if method == "" {
+		return nil, &JSONRPCError{
+			Code:    InvalidRequest,
+			Message: "Invalid Request",
+			Data:    "GET requests must include a method query parameter",
+		}
+	}
+	if s.hasMethod(method) && !s.isReadOnly(method) {
+		return nil, &JSONRPCError{
+			Code:    MethodNotFound,
+			Message: "Method not found",
+			Data:    fmt.Sprintf("Method '%s' changes server state and must be called with POST", method),
+		}
+	}
+
+	request := map[string]interface{}{
+		"jsonrpc": JSONRPCVersion,
+		"method":  method,
+	}
+	if query.Has("id") {
+		request["id"] = queryValue(query.Get("id"))
+	}
+
+	named := map[string]interface{}{}
+	for key, values := range query {
+		switch key {
+		case "method", "params", "id":
+			continue
+		}
+		if len(values) == 1 {
+			named[key] = queryValue(values[0])
+			continue
+		}
+		list := make([]interface{}, len(values))
+		for i, value := range values {
+			list[i] = queryValue(value)
+		}
+		named[key] = list
+	}
+
+	if query.Has("params") {
+		if len(named) > 0 {
+			return nil, &JSONRPCError{
+				Code:    InvalidRequest,
+				Message: "Invalid Request",
+				Data:    "Use either a params query parameter or individual query args, not both",
+			}
+		}
+		params := json.RawMessage(query.Get("params"))
+		if !json.Valid(params) {
+			return nil, &JSONRPCError{
+				Code:    ParseError,
+				Message: "Parse error",
+				Data:    "params query parameter must be valid JSON",
+			}
+		}
+		request["params"] = params
+	} else if len(named) > 0 {
+		request["params"] = named
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, &JSONRPCError{
+			Code:    InternalError,
+			Message: "Internal error",
+			Data:    err.Error(),
+		}
+	}
+	return body, nil
+}
+
+// queryValue interprets a query value as JSON (numbers, booleans, quoted strings)
+// and falls back to the raw string
+func queryValue(value string) interface{} {
+	if json.Valid([]byte(value)) {
+		return json.RawMessage(value)
+	}
+	return value
+}
@@ -0,0 +1,195 @@
+package calcrpc
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// DefaultIdempotencyTTL is how long a result stays cached under its idempotency key
+const DefaultIdempotencyTTL = 10 * time.Minute
+
+// DefaultIdempotencyCacheSize bounds the number of cached idempotency keys
+const DefaultIdempotencyCacheSize = 1000
+
+// idempotencyKeyParam is the params member that carries an idempotency key
+const idempotencyKeyParam = "idempotencyKey"
+
+// idempotencyKeyCtx is the context key under which an Idempotency-Key header is stored
+type idempotencyKeyCtx struct{}
+
+// withIdempotencyKey applies key to the single request handled with ctx
+func withIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtx{}, key)
+}
+
+// splitIdempotencyKey removes an optional string "idempotencyKey" member from named params
+func splitIdempotencyKey(params interface{}) (interface{}, string, error) {
+	fields, ok := params.(map[string]interface{})
+	if !ok {
+		return params, "", nil
+	}
+	raw, ok := fields[idempotencyKeyParam]
+	if !ok {
+		return params, "", nil
+	}
+
+	key, ok := raw.(string)
+	if !ok || key == "" {
+		return nil, "", invalidParams(idempotencyKeyParam, reasonType, "non-empty string", "Parameter 'idempotencyKey' must be a non-empty string")
+	}
+
+	stripped := make(map[string]interface{}, len(fields)-1)
+	for name, value := range fields {
+		if name != idempotencyKeyParam {
+			stripped[name] = value
+		}
+	}
+	return stripped, key, nil
+}
+
+// idempotencyEntry is one key's call: in flight until done is closed, then
+// holding its result until expires
+type idempotencyEntry struct {
+	key         string
+	fingerprint string // method and params the key was first used with
+	done        chan struct{}
+	result      interface{}
+	err         error
+	expires     time.Time
+	element     *list.Element
+}
+
+// idempotencyCache is an LRU of successful results by idempotency key, each
+// kept for a TTL. Concurrent calls with the same key wait for the first one.
+// Keys are shared by every client and transport, so clients must make them
+// globally unique (e.g. random UUIDs) rather than counting from 1
+type idempotencyCache struct {
+	mu      sync.Mutex
+	entries map[string]*idempotencyEntry
+	recent  list.List // of *idempotencyEntry, most recently used first
+}
+
+// idempotencyConflictError is returned when a key is reused for a different call
+func idempotencyConflictError(key string) error {
+	return &JSONRPCError{
+		Code:    InvalidRequest,
+		Message: "Invalid Request",
+		Data:    map[string]interface{}{"detail": "idempotency key already used for a different request", "idempotencyKey": key},
+	}
+}
+
+// do runs call once per key and fingerprint: later calls with the same key
+// get the cached result (replayed is true) until it expires. Errors are not
+// cached, so a failed call can be retried with the same key; calls that were
+// waiting on it run it again themselves rather than sharing its error
+func (c *idempotencyCache) do(key, fingerprint string, ttl time.Duration, size int, call func() (interface{}, error)) (result interface{}, replayed bool, err error) {
+	c.mu.Lock()
+	if c.entries == nil {
+		c.entries = make(map[string]*idempotencyEntry)
+	}
+	for {
+		entry, ok := c.entries[key]
+		if !ok || c.expired(entry) {
+			break
+		}
+		c.recent.MoveToFront(entry.element)
+		c.mu.Unlock()
+		if entry.fingerprint != fingerprint {
+			return nil, false, idempotencyConflictError(key)
+		}
+		<-entry.done
+		if entry.err == nil {
+			return entry.result, true, nil
+		}
+		c.mu.Lock()
+	}
+
+	entry := &idempotencyEntry{key: key, fingerprint: fingerprint, done: make(chan struct{})}
+	c.insert(entry, size)
+	c.mu.Unlock()
+
+	entry.result, entry.err = call()
+
+	c.mu.Lock()
+	if entry.err != nil {
+		c.remove(entry)
+	} else {
+		entry.expires = time.Now().Add(ttl)
+	}
+	close(entry.done)
+	c.mu.Unlock()
+	return entry.result, false, entry.err
+}
+
+// clear forgets every key and returns how many there were. Calls in flight
+// still finish, but their results are not replayed
+func (c *idempotencyCache) clear() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cleared := len(c.entries)
+	c.entries = nil
+	c.recent.Init()
+	return cleared
+}
+
+// expired reports whether a finished entry has outlived its TTL, removing it if so
+// Must be called with mu held
+func (c *idempotencyCache) expired(entry *idempotencyEntry) bool {
+	if entry.expires.IsZero() || time.Now().Before(entry.expires) {
+		return false
+	}
+	c.remove(entry)
+	return true
+}
+
+// insert adds entry, evicting the least recently used keys beyond size
+// Must be called with mu held
+func (c *idempotencyCache) insert(entry *idempotencyEntry, size int) {
+	if old, ok := c.entries[entry.key]; ok {
+		c.remove(old)
+	}
+	entry.element = c.recent.PushFront(entry)
+	c.entries[entry.key] = entry
+	for size > 0 && c.recent.Len() > size {
+		c.remove(c.recent.Back().Value.(*idempotencyEntry))
+	}
+}
+
+// remove drops entry from the cache if it is still the one stored for its key
+// Must be called with mu held
+func (c *idempotencyCache) remove(entry *idempotencyEntry) {
+	if c.entries[entry.key] != entry {
+		return
+	}
+	delete(c.entries, entry.key)
+	c.recent.Remove(entry.element)
+}
+
+// callIdempotent runs call for a request, deduplicating it by the request's
+// idempotency key (the idempotencyKey param, or the Idempotency-Key header for
+// a single HTTP request). Without a key, or with caching disabled, call just runs
+func (s *JSONRPCServer) callIdempotent(ctx context.Context, method string, params interface{}, call func(params interface{}) (interface{}, error)) (interface{}, bool, error) {
+	params, key, err := splitIdempotencyKey(params)
+	if err != nil {
+		return nil, false, err
+	}
+	if key == "" {
+		key, _ = ctx.Value(idempotencyKeyCtx{}).(string)
+	}
+	if key == "" || s.IdempotencyTTL <= 0 {
+		result, err := call(params)
+		return result, false, err
+	}
+
+	paramBytes, err := json.Marshal(params)
+	if err != nil {
+		return nil, false, invalidParams("", reasonInvalid, "", "Cannot marshal parameters")
+	}
+	fingerprint := method + "\x00" + string(paramBytes)
+	return s.idempotency.do(key, fingerprint, s.IdempotencyTTL, s.IdempotencyCacheSize, func() (interface{}, error) {
+		return call(params)
+	})
+}
@@ -0,0 +1,74 @@
+package calcrpc
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIdempotentReplayAndReset(t *testing.T) {
+	s := newTestServer()
+	add := `{"jsonrpc":"2.0","method":"mem.add","params":{"a":2,"idempotencyKey":"8d0c5f1e"},"id":1}`
+	recall := func() float64 {
+		var memory float64
+		decodeInto(t, callResponse(t, s, `{"jsonrpc":"2.0","method":"mem.recall","id":2}`), &memory)
+		return memory
+	}
+
+	callResponse(t, s, add)
+	callResponse(t, s, add)
+	if got := recall(); got != 2 {
+		t.Fatalf("memory after a replayed mem.add = %v, want 2", got)
+	}
+
+	var summary ResetSummary
+	decodeInto(t, callResponse(t, s, `{"jsonrpc":"2.0","method":"reset","id":3}`), &summary)
+	if summary.IdempotencyKeys != 1 {
+		t.Errorf("reset cleared %d idempotency keys, want 1", summary.IdempotencyKeys)
+	}
+
+	// The key was forgotten, so the same request runs again
+	callResponse(t, s, add)
+	if got := recall(); got != 2 {
+		t.Errorf("memory after reset and mem.add = %v, want 2", got)
+	}
+}
+
+func TestIdempotencyWaitersRetryFailedCall(t *testing.T) {
+	var c idempotencyCache
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go c.do("k", "f", time.Minute, 0, func() (interface{}, error) {
+		close(started)
+		<-release
+		return nil, errors.New("transient")
+	})
+	<-started
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	calls := 0
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, _, err := c.do("k", "f", time.Minute, 0, func() (interface{}, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				calls++
+				return "ok", nil
+			})
+			if err != nil || result != "ok" {
+				t.Errorf("waiter got %v, %v; want ok", result, err)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond) // let the waiters block on the failing call
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("retried call ran %d times, want 1", calls)
+	}
+}
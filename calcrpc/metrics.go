@@ -0,0 +1,51 @@
+package calcrpc
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Prometheus metrics exposed on /metrics
+var (
+	requestsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "jsonrpc_requests_total",
+		Help: "Total JSON-RPC payloads handled (a batch counts once).",
+	})
+
+	methodCallsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "jsonrpc_method_calls_total",
+		Help: "JSON-RPC method calls by method and call type (request or notification).",
+	}, []string{"method", "type"})
+
+	errorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "jsonrpc_errors_total",
+		Help: "JSON-RPC errors by error code.",
+	}, []string{"code"})
+
+	requestDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "jsonrpc_request_duration_seconds",
+		Help:    "Latency of HandleRequest in seconds.",
+		Buckets: prometheus.DefBuckets,
+	})
+)
+
+// observeCall counts a method call under its canonical name, labelling
+// unregistered methods as "unknown" so arbitrary client-supplied names (or
+// spellings like "MaTh.AdD") can't blow up label cardinality
+func (s *JSONRPCServer) observeCall(method string, callType string, err error) {
+	label, ok := s.canonicalMethod(method)
+	if !ok {
+		label = "unknown"
+	}
+	methodCallsTotal.WithLabelValues(label, callType).Inc()
+
+	if err != nil {
+		code := InternalError
+		if jsonrpcErr, ok := err.(*JSONRPCError); ok {
+			code = jsonrpcErr.Code
+		}
+		errorsTotal.WithLabelValues(strconv.Itoa(code)).Inc()
+	}
+}
@@ -0,0 +1,58 @@
+package calcrpc
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// methodLabels returns the method label of every jsonrpc_method_calls_total series
+func methodLabels(t *testing.T) map[string]bool {
+	t.Helper()
+	metrics := make(chan prometheus.Metric, 256)
+	go func() {
+		methodCallsTotal.Collect(metrics)
+		close(metrics)
+	}()
+
+	labels := make(map[string]bool)
+	for metric := range metrics {
+		var m dto.Metric
+		if err := metric.Write(&m); err != nil {
+			t.Fatal(err)
+		}
+		for _, label := range m.GetLabel() {
+			if label.GetName() == "method" {
+				labels[label.GetValue()] = true
+			}
+		}
+	}
+	return labels
+}
+
+func TestMethodCallsLabelledByCanonicalName(t *testing.T) {
+	s := newTestServer()
+	canonical := methodCallsTotal.WithLabelValues("math.add", "request")
+	before := testutil.ToFloat64(canonical)
+
+	spellings := []string{"math.add", "MaTh.AdD", "add", "ADD", "plus"}
+	for _, method := range spellings {
+		callResponse(t, s, `{"jsonrpc":"2.0","method":"`+method+`","params":[1,2],"id":1}`)
+	}
+	if got := testutil.ToFloat64(canonical) - before; got != float64(len(spellings)) {
+		t.Errorf("math.add counted %v calls, want %d", got, len(spellings))
+	}
+
+	callResponse(t, s, `{"jsonrpc":"2.0","method":"noSuchMethod","id":2}`)
+	labels := methodLabels(t)
+	for _, label := range append(spellings[1:], "noSuchMethod") {
+		if labels[label] {
+			t.Errorf("found a series labelled %q", label)
+		}
+	}
+	if !labels["unknown"] {
+		t.Error("unregistered method not labelled \"unknown\"")
+	}
+}
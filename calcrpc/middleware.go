@@ -0,0 +1,100 @@
+package calcrpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+)
+
+// Handler dispatches one method call and returns its result
+type Handler func(ctx context.Context, method string, params interface{}) (interface{}, error)
+
+// Middleware wraps a Handler, e.g. to check permissions, rewrite params or
+// time the call. It may call next, or return without calling it to reject
+// the call
+type Middleware func(next Handler) Handler
+
+// Use adds mw to the dispatch pipeline. Middlewares wrap every call, including
+// batch entries, notifications and subscription pushes; the first one added is
+// the outermost. The built-in logging and metrics middleware is always first,
+// followed by panic recovery
+func (s *JSONRPCServer) Use(mw Middleware) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.middleware = append(s.middleware, mw)
+}
+
+// withMiddleware wraps inner in every middleware added with Use
+func (s *JSONRPCServer) withMiddleware(inner Handler) Handler {
+	s.mu.RLock()
+	middleware := s.middleware
+	s.mu.RUnlock()
+
+	handler := inner
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
+
+// callInfo describes the client message a call was made for, for logging
+type callInfo struct {
+	logger   *slog.Logger
+	callType string      // "request" or "notification"
+	id       interface{} // the request id; nil for notifications
+}
+
+// callInfoKey is the context key under which the current callInfo is stored
+type callInfoKey struct{}
+
+// withCallInfo attaches the message being handled to ctx
+func withCallInfo(ctx context.Context, info callInfo) context.Context {
+	return context.WithValue(ctx, callInfoKey{}, info)
+}
+
+// observeCalls is the default middleware: it logs every client call and
+// records it in the Prometheus metrics. Internal calls such as the health
+// self-check carry no callInfo and pass through unrecorded
+func (s *JSONRPCServer) observeCalls(next Handler) Handler {
+	return func(ctx context.Context, method string, params interface{}) (interface{}, error) {
+		info, ok := ctx.Value(callInfoKey{}).(callInfo)
+		if !ok {
+			return next(ctx, method, params)
+		}
+
+		start := time.Now()
+		result, err := next(ctx, method, params)
+		s.observeCall(method, info.callType, err)
+		logCall(info.logger, info.callType+" handled", method, info.id, start, err)
+		return result, err
+	}
+}
+
+// recoverCalls is the built-in middleware that turns a panic in a later
+// middleware into an Internal error for that call alone. Panics in methods are
+// recovered where they run, in callMethodWithTimeout
+func (s *JSONRPCServer) recoverCalls(next Handler) Handler {
+	return func(ctx context.Context, method string, params interface{}) (result interface{}, err error) {
+		defer s.recoverPanic(method, &err)
+		return next(ctx, method, params)
+	}
+}
+
+// recoverPanic must be deferred directly. It converts a panic into an Internal
+// error in *err, logging the panic and its stack trace; clients only see the
+// panic value when VerboseErrors is set
+func (s *JSONRPCServer) recoverPanic(method string, err *error) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+
+	s.logger.Error("method panicked", "method", method, "panic", recovered, "stack", string(debug.Stack()))
+	*err = &JSONRPCError{
+		Code:    InternalError,
+		Message: "Internal error",
+		Data:    fmt.Sprintf("panic in %s: %v", method, recovered),
+	}
+}
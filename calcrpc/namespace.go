@@ -0,0 +1,92 @@
+package calcrpc
+
+import "strings"
+
+// flatMethodNames maps un-namespaced method names to their namespaced
+// equivalents; they remain registered as aliases
+var flatMethodNames = map[string]string{
+	"add":             "math.add",
+	"intAdd":          "math.intAdd",
+	"subtract":        "math.subtract",
+	"multiply":        "math.multiply",
+	"divide":          "math.divide",
+	"divmod":          "math.divmod",
+	"power":           "math.power",
+	"modulo":          "math.modulo",
+	"percent":         "math.percent",
+	"percentChange":   "math.percentChange",
+	"sqrt":            "math.sqrt",
+	"abs":             "math.abs",
+	"floor":           "math.floor",
+	"ceil":            "math.ceil",
+	"round":           "math.round",
+	"sin":             "math.sin",
+	"cos":             "math.cos",
+	"tan":             "math.tan",
+	"asin":            "math.asin",
+	"acos":            "math.acos",
+	"atan":            "math.atan",
+	"atan2":           "math.atan2",
+	"sinh":            "math.sinh",
+	"cosh":            "math.cosh",
+	"tanh":            "math.tanh",
+	"log":             "math.log",
+	"gcd":             "math.gcd",
+	"lcm":             "math.lcm",
+	"factorial":       "math.factorial",
+	"combinations":    "math.combinations",
+	"fibonacci":       "math.fibonacci",
+	"isPrime":         "math.isPrime",
+	"chain":           "math.chain",
+	"eval":            "math.eval",
+	"evalBatch":       "math.evalBatch",
+	"baseConvert":     "math.baseConvert",
+	"clamp":           "math.clamp",
+	"and":             "bits.and",
+	"or":              "bits.or",
+	"xor":             "bits.xor",
+	"not":             "bits.not",
+	"shiftLeft":       "bits.shiftLeft",
+	"shiftRight":      "bits.shiftRight",
+	"convert":         "units.convert",
+	"sum":             "stats.sum",
+	"product":         "stats.product",
+	"min":             "stats.min",
+	"max":             "stats.max",
+	"average":         "stats.average",
+	"median":          "stats.median",
+	"variance":        "stats.variance",
+	"stddev":          "stats.stddev",
+	"preciseAdd":      "decimal.add",
+	"preciseSubtract": "decimal.subtract",
+	"preciseMultiply": "decimal.multiply",
+	"preciseDivide":   "decimal.divide",
+	"bigAdd":          "big.add",
+	"bigSub":          "big.sub",
+	"bigMul":          "big.mul",
+	"memStore":        "mem.store",
+	"memRecall":       "mem.recall",
+	"memClear":        "mem.clear",
+	"memAdd":          "mem.add",
+	"undo":            "mem.undo"}
+
+// methodNamespace returns the part of a method name before its last dot
+// ("math" for "math.add"), or "" for un-namespaced names such as "ping"
+func methodNamespace(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+// groupByNamespace groups namespaced method names by namespace
+// Un-namespaced names are left out
+func groupByNamespace(names []string) map[string][]string {
+	groups := make(map[string][]string)
+	for _, name := range names {
+		if namespace := methodNamespace(name); namespace != "" {
+			groups[namespace] = append(groups[namespace], name)
+		}
+	}
+	return groups
+}
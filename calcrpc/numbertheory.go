@@ -0,0 +1,68 @@
+package calcrpc
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// maxExactFibonacci is the largest n whose Fibonacci number fits exactly in a float64
+const maxExactFibonacci = 78
+
+// maxFibonacci bounds fibonacci so one request can't demand unbounded big-integer work
+const maxFibonacci = 10000
+
+// FibonacciParams represents parameters for the n-th Fibonacci number
+type FibonacciParams struct {
+	N float64 `json:"n"`
+}
+
+// Validate ensures n is a non-negative whole number
+func (p *FibonacciParams) Validate() error {
+	return validateWholeNumber("n", p.N)
+}
+
+// PrimeParams represents parameters for primality testing
+type PrimeParams struct {
+	N float64 `json:"n"`
+}
+
+// Validate ensures n is a non-negative whole number no larger than 2^53
+func (p *PrimeParams) Validate() error {
+	if err := validateWholeNumber("n", p.N); err != nil {
+		return err
+	}
+	return validateInteger("n", p.N)
+}
+
+// Fibonacci returns F(n) with F(0) = 0 and F(1) = 1
+// Results up to F(78) are numbers; larger ones are exact base-10 strings
+func (c *Calculator) Fibonacci(params FibonacciParams) (interface{}, error) {
+	if params.N > maxFibonacci {
+		return nil, &JSONRPCError{
+			Code:    -32000, // Application error
+			Message: "Result too large",
+			Data:    fmt.Sprintf("fibonacci(%g) exceeds the limit (max n is %d)", params.N, maxFibonacci),
+		}
+	}
+
+	a, b := big.NewInt(0), big.NewInt(1)
+	for i := 0; i < int(params.N); i++ {
+		a.Add(a, b)
+		a, b = b, a
+	}
+
+	var result interface{} = a.String()
+	if params.N <= maxExactFibonacci {
+		result = float64(a.Int64())
+	}
+	c.logger.Debug("calculation", "operation", "fibonacci", "n", params.N, "result", result)
+	return result, nil
+}
+
+// IsPrime reports whether n is prime
+// ProbablyPrime is exact for inputs below 2^64, which covers every valid n
+func (c *Calculator) IsPrime(params PrimeParams) (bool, error) {
+	result := big.NewInt(int64(params.N)).ProbablyPrime(0)
+	c.logger.Debug("calculation", "operation", "isPrime", "n", params.N, "result", result)
+	return result, nil
+}
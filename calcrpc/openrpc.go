@@ -0,0 +1,149 @@
+package calcrpc
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// OpenRPCVersion is the OpenRPC specification version of the rpc.discover document
+const OpenRPCVersion = "1.3.2"
+
+// OpenRPCDocument is the service description returned by rpc.discover
+type OpenRPCDocument struct {
+	OpenRPC string          `json:"openrpc"`
+	Info    OpenRPCInfo     `json:"info"`
+	Methods []OpenRPCMethod `json:"methods"`
+}
+
+// OpenRPCInfo holds service metadata
+type OpenRPCInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// OpenRPCMethod describes a single method
+type OpenRPCMethod struct {
+	Name           string                     `json:"name"`
+	Summary        string                     `json:"summary,omitempty"`
+	ParamStructure string                     `json:"paramStructure,omitempty"`
+	Params         []OpenRPCContentDescriptor `json:"params"`
+	Result         *OpenRPCContentDescriptor  `json:"result,omitempty"` // omitted for notifications
+	Tags           []OpenRPCTag               `json:"tags,omitempty"`   // the method's namespace, if any
+}
+
+// OpenRPCTag groups methods; methods are tagged with their namespace
+type OpenRPCTag struct {
+	Name string `json:"name"`
+}
+
+// OpenRPCContentDescriptor describes a param or result with a JSON Schema
+type OpenRPCContentDescriptor struct {
+	Name     string                 `json:"name"`
+	Required bool                   `json:"required,omitempty"`
+	Schema   map[string]interface{} `json:"schema"`
+}
+
+// openRPCDocument builds the OpenRPC description from the method registry
+func (s *JSONRPCServer) openRPCDocument() OpenRPCDocument {
+	doc := OpenRPCDocument{
+		OpenRPC: OpenRPCVersion,
+		Info:    OpenRPCInfo{Title: serviceName, Version: serviceVersion},
+		Methods: []OpenRPCMethod{},
+	}
+
+	for _, name := range s.methodNames() {
+		// rpc.discover describes the service, not itself
+		if name == "rpc.discover" {
+			continue
+		}
+
+		entry, ok := s.lookupMethod(name)
+		if !ok {
+			continue
+		}
+
+		method := OpenRPCMethod{
+			Name:    name,
+			Summary: entry.info.Summary,
+			Params:  openRPCParams(entry.info.Params),
+		}
+		if len(method.Params) > 0 {
+			method.ParamStructure = "by-name"
+		}
+		if namespace := methodNamespace(name); namespace != "" {
+			method.Tags = []OpenRPCTag{{Name: namespace}}
+		}
+		if entry.info.Result != nil {
+			method.Result = &OpenRPCContentDescriptor{
+				Name:   "result",
+				Schema: jsonSchema(entry.info.Result),
+			}
+		}
+		doc.Methods = append(doc.Methods, method)
+	}
+
+	return doc
+}
+
+// openRPCParams describes each field of a params struct as a named param
+func openRPCParams(t reflect.Type) []OpenRPCContentDescriptor {
+	params := []OpenRPCContentDescriptor{}
+	if t == nil || t.Kind() != reflect.Struct {
+		return params
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		name, opts, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		// Fields without omitempty are required (see checkRequiredParams)
+		params = append(params, OpenRPCContentDescriptor{
+			Name:     name,
+			Required: !strings.Contains(opts, "omitempty"),
+			Schema:   jsonSchema(t.Field(i).Type),
+		})
+	}
+	return params
+}
+
+// jsonSchema returns a JSON Schema for a Go type
+func jsonSchema(t reflect.Type) map[string]interface{} {
+	if t == reflect.TypeOf(json.RawMessage{}) {
+		// Raw JSON is passed through untouched: any JSON value
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": jsonSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object"}
+	case reflect.Struct:
+		if t.PkgPath() == "time" && t.Name() == "Time" {
+			return map[string]interface{}{"type": "string", "format": "date-time"}
+		}
+
+		properties := map[string]interface{}{}
+		for _, param := range openRPCParams(t) {
+			properties[param.Name] = param.Schema
+		}
+		return map[string]interface{}{"type": "object", "properties": properties}
+	case reflect.Pointer:
+		return jsonSchema(t.Elem())
+	default:
+		// interface{} and anything else: any JSON value
+		return map[string]interface{}{}
+	}
+}
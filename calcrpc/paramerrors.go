@@ -0,0 +1,43 @@
+package calcrpc
+
+// Reasons reported in InvalidParamsDetail
+const (
+	reasonRequired   = "required"   // a required parameter is missing
+	reasonUnknown    = "unknown"    // a parameter the method doesn't take
+	reasonType       = "type"       // the JSON type is wrong, e.g. a string for a number
+	reasonInvalid    = "invalid"    // the value is malformed, e.g. "1.2.3" as a decimal
+	reasonRange      = "range"      // the value is outside the accepted range
+	reasonUnexpected = "unexpected" // params were sent to a method that takes none
+)
+
+// InvalidParamsDetail is the structured Data of an Invalid params error
+// Clients receive it when StructuredParamErrors is set; otherwise Data is
+// just Message, as before structured errors existed
+type InvalidParamsDetail struct {
+	Field    string `json:"field,omitempty"`    // parameter name, e.g. "b" or "ops[2].op"
+	Reason   string `json:"reason"`             // one of the reason* constants
+	Expected string `json:"expected,omitempty"` // what would have been accepted
+	Message  string `json:"message"`            // human-readable description
+}
+
+// invalidParams creates an Invalid params error with structured Data
+func invalidParams(field string, reason string, expected string, message string) *JSONRPCError {
+	return &JSONRPCError{
+		Code:    InvalidParams,
+		Message: "Invalid params",
+		Data:    InvalidParamsDetail{Field: field, Reason: reason, Expected: expected, Message: message},
+	}
+}
+
+// paramsErrorData returns the Data to send for an Invalid params error: the
+// detail object when structured is set, or its message string otherwise
+func paramsErrorData(data interface{}, structured bool) interface{} {
+	detail, isDetail := data.(InvalidParamsDetail)
+	if isDetail && !structured {
+		return detail.Message
+	}
+	if text, isText := data.(string); isText && structured {
+		return InvalidParamsDetail{Reason: reasonInvalid, Message: text}
+	}
+	return data
+}
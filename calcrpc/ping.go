@@ -0,0 +1,34 @@
+package calcrpc
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// PingParams represents the optional parameters of ping
+type PingParams struct {
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// PingResult is the ping result when the client sent a payload
+type PingResult struct {
+	Message string          `json:"message"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// ping answers "pong", echoing the client's payload if it sent one
+// Lets stream transports (WebSocket, TCP, stdio) check the RPC pipeline in-band
+func ping(params json.RawMessage) (interface{}, error) {
+	if len(params) == 0 {
+		return "pong", nil
+	}
+
+	var p PingParams
+	if err := decodeParams(params, &p, paramsUsage(reflect.TypeOf(p))); err != nil {
+		return nil, err
+	}
+	if p.Payload == nil {
+		return "pong", nil
+	}
+	return PingResult{Message: "pong", Payload: p.Payload}, nil
+}
@@ -0,0 +1,251 @@
+package calcrpc
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// MethodHandler handles a JSON-RPC method call given its raw params
+// ctx is cancelled when the client goes away or the request deadline passes
+type MethodHandler func(ctx context.Context, params json.RawMessage) (interface{}, error)
+
+// MethodInfo describes a registered method for service discovery
+type MethodInfo struct {
+	Summary string
+	Params  reflect.Type // struct decoded from named params; nil if the method takes none
+	Result  reflect.Type // nil for methods that return nothing (notifications)
+
+	// Notification marks a notification-only method; calling it as a request
+	// (with an id) is rejected instead of answering with a null result
+	Notification bool
+
+	// ReadOnly marks a method without side effects, which may also be
+	// invoked over HTTP GET
+	ReadOnly bool
+
+	// NoParams marks a method that takes no parameters; supplying any is
+	// rejected with Invalid params rather than silently ignored
+	NoParams bool
+
+	// Validate checks params without running the method, for validate-only
+	// requests. When nil, params are only decoded into Params, if given
+	Validate func(params json.RawMessage) error
+
+	// Defaults are filled in for named params a call leaves out, but only when
+	// the server runs with LenientDefaults; e.g. {"b": 0} for math.add
+	Defaults map[string]interface{}
+}
+
+// MethodCatalog lists the registered methods for getInfo
+type MethodCatalog struct {
+	Methods       []string            // canonical request methods
+	Notifications []string            // notification-only methods
+	Aliases       map[string][]string // canonical name -> its aliases
+	Namespaces    map[string][]string // namespace -> its methods (namespaced names only)
+}
+
+// registeredMethod is a method registry entry
+type registeredMethod struct {
+	handler MethodHandler
+	info    MethodInfo
+}
+
+// RegisterMethod adds (or replaces) a method available to JSON-RPC clients
+func (s *JSONRPCServer) RegisterMethod(name string, handler MethodHandler) {
+	s.RegisterMethodWithInfo(name, handler, MethodInfo{})
+}
+
+// RegisterMethodWithInfo adds (or replaces) a method along with the description
+// published by rpc.discover
+func (s *JSONRPCServer) RegisterMethodWithInfo(name string, handler MethodHandler, info MethodInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.methods[name] = &registeredMethod{handler: handler, info: info}
+	s.folded[strings.ToLower(name)] = name
+}
+
+// RegisterAlias makes alias call the same handler as the canonical method
+// The canonical method is resolved at call time, so it may be registered later
+func (s *JSONRPCServer) RegisterAlias(alias string, canonical string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.aliases[alias] = canonical
+	s.folded[strings.ToLower(alias)] = alias
+}
+
+// lookupMethod returns the registry entry for name, following aliases
+// An exact match wins; otherwise names are matched case-insensitively so
+// "ADD" and "PercentChange" resolve to "add" and "percentChange"
+func (s *JSONRPCServer) lookupMethod(name string) (*registeredMethod, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entry, ok := s.methods[s.resolveMethod(name)]
+	return entry, ok
+}
+
+// canonicalMethod returns the name the method called name is registered
+// under, e.g. "math.add" for "MaTh.AdD" or for its alias "add"
+func (s *JSONRPCServer) canonicalMethod(name string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	canonical := s.resolveMethod(name)
+	_, ok := s.methods[canonical]
+	return canonical, ok
+}
+
+// resolveMethod maps name to a key of s.methods, following case folding and
+// aliases; the caller must hold s.mu
+func (s *JSONRPCServer) resolveMethod(name string) string {
+	if _, exact := s.methods[name]; !exact {
+		if _, exact = s.aliases[name]; !exact {
+			if registered, ok := s.folded[strings.ToLower(name)]; ok {
+				name = registered
+			}
+		}
+	}
+
+	if _, ok := s.methods[name]; !ok {
+		if canonical, isAlias := s.aliases[name]; isAlias {
+			return canonical
+		}
+	}
+	return name
+}
+
+// methodAliases returns the sorted aliases of each canonical method that has any
+func (s *JSONRPCServer) methodAliases() map[string][]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	aliases := make(map[string][]string)
+	for alias, canonical := range s.aliases {
+		aliases[canonical] = append(aliases[canonical], alias)
+	}
+	for _, names := range aliases {
+		sort.Strings(names)
+	}
+	return aliases
+}
+
+// hasMethod reports whether a method is registered under name
+func (s *JSONRPCServer) hasMethod(name string) bool {
+	_, ok := s.lookupMethod(name)
+	return ok
+}
+
+// isNotificationOnly reports whether name is registered as notification-only
+func (s *JSONRPCServer) isNotificationOnly(name string) bool {
+	entry, ok := s.lookupMethod(name)
+	return ok && entry.info.Notification
+}
+
+// isReadOnly reports whether name is registered as free of side effects
+func (s *JSONRPCServer) isReadOnly(name string) bool {
+	entry, ok := s.lookupMethod(name)
+	return ok && entry.info.ReadOnly
+}
+
+// validateMethod checks that method exists and accepts params, without calling it
+func (s *JSONRPCServer) validateMethod(method string, params interface{}) error {
+	entry, ok := s.lookupMethod(method)
+	if !ok {
+		return s.methodNotFoundError(method)
+	}
+
+	var rawParams json.RawMessage
+	if params != nil {
+		var err error
+		if rawParams, err = json.Marshal(params); err != nil {
+			return invalidParams("", reasonInvalid, "", "Cannot marshal parameters")
+		}
+	}
+
+	if s.LenientDefaults {
+		rawParams = withDefaults(rawParams, entry.info.Defaults)
+	}
+	if entry.info.NoParams && hasParams(rawParams) {
+		return noParamsError()
+	}
+	if entry.info.Validate != nil {
+		return entry.info.Validate(rawParams)
+	}
+	if entry.info.Params != nil && hasParams(rawParams) {
+		target := reflect.New(entry.info.Params)
+		if err := decodeParams(rawParams, target.Interface(), paramsUsage(entry.info.Params)); err != nil {
+			return err
+		}
+		if v, ok := target.Interface().(paramsValidator); ok {
+			return v.Validate()
+		}
+	}
+	return nil
+}
+
+// withDefaults adds the defaults missing from named (or absent) params
+// Positional params are returned unchanged
+func withDefaults(params json.RawMessage, defaults map[string]interface{}) json.RawMessage {
+	if len(defaults) == 0 {
+		return params
+	}
+
+	fields := map[string]json.RawMessage{}
+	if hasParams(params) {
+		if params[0] != '{' || json.Unmarshal(params, &fields) != nil {
+			return params
+		}
+	}
+	for name, value := range defaults {
+		if _, present := fields[name]; !present {
+			fields[name], _ = json.Marshal(value)
+		}
+	}
+
+	filled, err := json.Marshal(fields)
+	if err != nil {
+		return params
+	}
+	return filled
+}
+
+// methodCatalog describes the registry for getInfo
+func (s *JSONRPCServer) methodCatalog() MethodCatalog {
+	return MethodCatalog{
+		Methods:       s.requestMethodNames(),
+		Notifications: s.notificationMethodNames(),
+		Aliases:       s.methodAliases(),
+		Namespaces:    groupByNamespace(s.methodNames()),
+	}
+}
+
+// methodNames returns the sorted names of all registered methods
+func (s *JSONRPCServer) methodNames() []string {
+	return s.filterMethodNames(func(*registeredMethod) bool { return true })
+}
+
+// requestMethodNames returns the sorted names of methods callable as requests
+func (s *JSONRPCServer) requestMethodNames() []string {
+	return s.filterMethodNames(func(entry *registeredMethod) bool { return !entry.info.Notification })
+}
+
+// notificationMethodNames returns the sorted names of notification-only methods
+func (s *JSONRPCServer) notificationMethodNames() []string {
+	return s.filterMethodNames(func(entry *registeredMethod) bool { return entry.info.Notification })
+}
+
+// filterMethodNames returns the sorted names of registered methods matching keep
+func (s *JSONRPCServer) filterMethodNames(keep func(*registeredMethod) bool) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.methods))
+	for name, entry := range s.methods {
+		if keep(entry) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
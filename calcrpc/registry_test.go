@@ -0,0 +1,50 @@
+package calcrpc
+
+import "testing"
+
+func TestMixedCaseMethodNames(t *testing.T) {
+	s := newTestServer()
+	tests := []struct {
+		method string
+		want   float64
+	}{
+		{"Add", 8},
+		{"ADD", 8},
+		{"SUBTRACT", 4},
+		{"Subtract", 4},
+		{"mUlTiPlY", 12},
+		{"MULTIPLY", 12},
+		{"DIVIDE", 3},
+		{"Divide", 3},
+		{"Math.Divide", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			response := callResponse(t, s, `{"jsonrpc":"2.0","method":"`+tt.method+`","params":{"a":6,"b":2},"id":1}`)
+			var result float64
+			decodeInto(t, response, &result)
+			if result != tt.want {
+				t.Errorf("%s(6, 2) = %v, want %v", tt.method, result, tt.want)
+			}
+		})
+	}
+}
+
+func TestNoParamsMethod(t *testing.T) {
+	s := newTestServer()
+
+	for _, body := range []string{
+		`{"jsonrpc":"2.0","method":"getInfo","id":1}`,
+		`{"jsonrpc":"2.0","method":"getInfo","params":{},"id":1}`,
+		`{"jsonrpc":"2.0","method":"getInfo","params":[],"id":1}`,
+	} {
+		if code := callError(t, s, body); code != 0 {
+			t.Errorf("%s failed with %d", body, code)
+		}
+	}
+
+	response := callResponse(t, s, `{"jsonrpc":"2.0","method":"getInfo","params":{"a":1},"id":2}`)
+	if response.Error == nil || response.Error.Code != InvalidParams || response.Error.Data != "this method takes no parameters" {
+		t.Errorf("error = %+v, want InvalidParams \"this method takes no parameters\"", response.Error)
+	}
+}
@@ -0,0 +1,132 @@
+package calcrpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// TransportError is a client failure where the server's JSON-RPC answer never
+// arrived: the connection failed or timed out, or something in between replied
+// with a non-JSON 5xx. Only these errors are ever retried
+type TransportError struct {
+	StatusCode int // HTTP status of a non-JSON reply; 0 when no reply arrived
+	Err        error
+}
+
+func (e *TransportError) Error() string {
+	return "calcrpc: transport error: " + e.Err.Error()
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// RetryPolicy decides whether a call that failed with a *TransportError is sent again.
+// JSON-RPC error responses are deterministic and never reach the policy
+type RetryPolicy interface {
+	// Retry reports whether to retry method after its attempt-th try (1-based)
+	// failed with err, and how long to wait first
+	Retry(method string, attempt int, err error) (delay time.Duration, ok bool)
+}
+
+// Defaults used by ExponentialBackoff fields left at zero
+const (
+	DefaultRetryAttempts  = 3
+	DefaultRetryBaseDelay = 100 * time.Millisecond
+	DefaultRetryMaxDelay  = 5 * time.Second
+)
+
+// ExponentialBackoff retries idempotent methods with delays of BaseDelay,
+// 2*BaseDelay, 4*BaseDelay, ... up to MaxDelay, for at most MaxAttempts tries
+type ExponentialBackoff struct {
+	MaxAttempts int           // total tries including the first; 0 uses DefaultRetryAttempts
+	BaseDelay   time.Duration // 0 uses DefaultRetryBaseDelay
+	MaxDelay    time.Duration // 0 uses DefaultRetryMaxDelay
+
+	// Idempotent reports whether method is safe to send twice; nil uses
+	// IdempotentMethod
+	Idempotent func(method string) bool
+}
+
+// Retry implements RetryPolicy
+func (p ExponentialBackoff) Retry(method string, attempt int, err error) (time.Duration, bool) {
+	maxAttempts, delay, maxDelay := p.MaxAttempts, p.BaseDelay, p.MaxDelay
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultRetryAttempts
+	}
+	if delay <= 0 {
+		delay = DefaultRetryBaseDelay
+	}
+	if maxDelay <= 0 {
+		maxDelay = DefaultRetryMaxDelay
+	}
+
+	idempotent := p.Idempotent
+	if idempotent == nil {
+		idempotent = IdempotentMethod
+	}
+	if attempt >= maxAttempts || !idempotent(method) {
+		return 0, false
+	}
+
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay), true
+}
+
+// stateChangingMethods are the built-in methods whose effect would be applied
+// twice if a retried request had in fact reached the server (lowercased, as
+// method names match case-insensitively)
+var stateChangingMethods = map[string]bool{
+	"mem.store":    true,
+	"mem.add":      true,
+	"mem.clear":    true,
+	"mem.undo":     true,
+	"memstore":     true,
+	"memadd":       true,
+	"memclear":     true,
+	"undo":         true,
+	"clearhistory": true,
+	"reset":        true,
+	"logmessage":   true,
+	"subscribe":    true,
+	"unsubscribe":  true,
+}
+
+// IdempotentMethod reports whether a built-in method can safely be retried:
+// everything except the memory, history and reset methods and logMessage
+func IdempotentMethod(method string) bool {
+	return !stateChangingMethods[strings.ToLower(method)]
+}
+
+// withRetry runs send, retrying transport errors as c.Retry allows for every
+// one of methods (a batch is retried only when all its calls are idempotent)
+func (c *Client) withRetry(ctx context.Context, methods []string, send func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := send()
+		var transportErr *TransportError
+		if err == nil || c.Retry == nil || !errors.As(err, &transportErr) || ctx.Err() != nil {
+			return err
+		}
+
+		var delay time.Duration
+		for _, method := range methods {
+			methodDelay, ok := c.Retry.Retry(method, attempt, err)
+			if !ok {
+				return err
+			}
+			delay = max(delay, methodDelay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,1323 @@
+// Package calcrpc is a JSON-RPC 2.0 calculator: the method registry and
+// dispatcher (JSONRPCServer), the Calculator behind the built-in methods, and
+// the HTTP, WebSocket, TCP and stdio transports that serve them
+package calcrpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultRequestTimeout is the per-request timeout used unless overridden
+const DefaultRequestTimeout = 5 * time.Second
+
+// DefaultMaxRequestTimeout caps per-request timeout overrides unless changed
+const DefaultMaxRequestTimeout = 60 * time.Second
+
+// DefaultMaxBatchSize is the batch size limit unless MaxBatchSize is changed
+const DefaultMaxBatchSize = 100
+
+// JSONRPCServer handles JSON-RPC requests
+type JSONRPCServer struct {
+	// RequestTimeout bounds how long a single method call may run
+	RequestTimeout time.Duration
+
+	// MaxRequestTimeout caps a timeout requested by the client (see
+	// withRequestTimeout); 0 leaves overrides uncapped
+	MaxRequestTimeout time.Duration
+
+	// VerboseErrors exposes the Data of Internal error responses to clients.
+	// Off by default so Go error strings and internals stay in the server log;
+	// application errors (e.g. division by zero) always keep their Data
+	VerboseErrors bool
+
+	// StructuredParamErrors sends Invalid params Data as an InvalidParamsDetail
+	// object instead of a message string
+	StructuredParamErrors bool
+
+	// LenientDefaults fills a missing operand with the operation's identity
+	// element (MethodInfo.Defaults) instead of rejecting the call
+	LenientDefaults bool
+
+	// MaxBatchSize caps the number of messages in a batch; 0 disables the limit
+	MaxBatchSize int
+
+	// BatchWorkers bounds how many batch entries run concurrently;
+	// 0 uses GOMAXPROCS
+	BatchWorkers int
+
+	// IdempotencyTTL is how long a successful result is replayed for retries
+	// carrying the same idempotency key; 0 disables idempotency keys
+	IdempotencyTTL time.Duration
+
+	// IdempotencyCacheSize bounds the number of idempotency keys remembered,
+	// evicting the least recently used; 0 leaves it unbounded
+	IdempotencyCacheSize int
+
+	// ResultDigits rounds float results to this many significant digits;
+	// 0 keeps full precision
+	ResultDigits int
+
+	// MaxExprDepth caps the nesting of parentheses, function calls and unary
+	// signs in eval expressions; 0 uses DefaultMaxExprDepth
+	MaxExprDepth int
+
+	logger     *slog.Logger
+	calculator *Calculator
+	history    *History
+
+	mu      sync.RWMutex
+	methods map[string]*registeredMethod
+	aliases map[string]string // alias -> canonical method name
+	folded  map[string]string // lowercased method or alias -> registered name
+	hooks   []CallHook        // see AddCallHook
+
+	middleware []Middleware // see Use
+
+	ready atomic.Bool // set once NewJSONRPCServer has populated the registry
+
+	broadcast broadcaster // server-pushed notifications, see Subscribe
+
+	idempotency idempotencyCache // results by idempotency key, see callIdempotent
+
+	transports   map[string]bool // names of the transports serving this server, see addTransport
+	authRequired bool            // whether any of them requires an API key
+}
+
+// NewJSONRPCServer creates a new JSON-RPC server with the calculator methods registered
+// A nil logger falls back to slog.Default()
+func NewJSONRPCServer(logger *slog.Logger) *JSONRPCServer {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	s := &JSONRPCServer{
+		RequestTimeout:       DefaultRequestTimeout,
+		MaxRequestTimeout:    DefaultMaxRequestTimeout,
+		MaxBatchSize:         DefaultMaxBatchSize,
+		IdempotencyTTL:       DefaultIdempotencyTTL,
+		IdempotencyCacheSize: DefaultIdempotencyCacheSize,
+		MaxExprDepth:         DefaultMaxExprDepth,
+		logger:               logger,
+		calculator:           NewCalculator(logger),
+		history:              &History{},
+		methods:              make(map[string]*registeredMethod),
+		aliases:              make(map[string]string),
+		folded:               make(map[string]string),
+	}
+	s.Use(s.observeCalls)
+	s.Use(s.recoverCalls)
+	s.calculator.functions = s.exprFunction
+	s.calculator.maxExprDepth = func() int { return s.MaxExprDepth }
+
+	s.registerCalculatorMethod("math.add", "Add", "Add b to a")
+	s.registerCalculatorMethod("math.intAdd", "IntAdd", "Exact int64 a + b with overflow detection")
+	s.registerCalculatorMethod("math.subtract", "Subtract", "Subtract b from a")
+	s.registerCalculatorMethod("math.multiply", "Multiply", "Multiply a by b")
+	s.registerCalculatorMethod("math.divide", "Divide", "Divide a by b")
+	s.registerCalculatorMethod("decimal.add", "PreciseAdd", "Exact decimal a + b (string operands and result)")
+	s.registerCalculatorMethod("decimal.subtract", "PreciseSubtract", "Exact decimal a - b (string operands and result)")
+	s.registerCalculatorMethod("decimal.multiply", "PreciseMultiply", "Exact decimal a * b (string operands and result)")
+	s.registerCalculatorMethod("decimal.divide", "PreciseDivide", "Decimal a / b (string operands and result)")
+	s.registerCalculatorMethod("big.add", "BigAdd", "Exact integer a + b (string operands and result)")
+	s.registerCalculatorMethod("big.sub", "BigSub", "Exact integer a - b (string operands and result)")
+	s.registerCalculatorMethod("big.mul", "BigMul", "Exact integer a * b (string operands and result)")
+	s.registerCalculatorMethod("math.divmod", "DivMod", "Truncated quotient and remainder of a / b")
+	s.registerCalculatorMethod("math.power", "Power", "Raise a to the power of b")
+	s.registerCalculatorMethod("math.modulo", "Modulo", "Remainder of a / b, taking the sign of a")
+	s.registerCalculatorMethod("math.percent", "Percent", "a percent of b")
+	s.registerCalculatorMethod("math.percentChange", "PercentChange", "Percentage change from a to b")
+	s.registerCalculatorMethod("math.sqrt", "Sqrt", "Square root of value")
+	s.registerCalculatorMethod("math.abs", "Abs", "Absolute value")
+	s.registerCalculatorMethod("math.floor", "Floor", "Round value down to an integer")
+	s.registerCalculatorMethod("math.ceil", "Ceil", "Round value up to an integer")
+	s.registerCalculatorMethod("math.round", "Round", "Round value to digits decimal places (default 0)")
+	s.registerCalculatorMethod("math.sin", "Sin", "Sine of an angle")
+	s.registerCalculatorMethod("math.cos", "Cos", "Cosine of an angle")
+	s.registerCalculatorMethod("math.tan", "Tan", "Tangent of an angle")
+	s.registerCalculatorMethod("math.asin", "Asin", "Arc sine of value in [-1, 1], in radians")
+	s.registerCalculatorMethod("math.acos", "Acos", "Arc cosine of value in [-1, 1], in radians")
+	s.registerCalculatorMethod("math.atan", "Atan", "Arc tangent of value, in radians")
+	s.registerCalculatorMethod("math.atan2", "Atan2", "Angle of the point (x=b, y=a), in radians")
+	s.registerCalculatorMethod("math.sinh", "Sinh", "Hyperbolic sine of value")
+	s.registerCalculatorMethod("math.cosh", "Cosh", "Hyperbolic cosine of value")
+	s.registerCalculatorMethod("math.tanh", "Tanh", "Hyperbolic tangent of value")
+	s.registerCalculatorMethod("math.log", "Log", "Logarithm of value in base (default e)")
+	s.registerCalculatorMethod("math.gcd", "GCD", "Greatest common divisor of integers a and b")
+	s.registerCalculatorMethod("math.lcm", "LCM", "Least common multiple of integers a and b")
+	s.registerCalculatorMethod("math.factorial", "Factorial", "n! for a whole number n <= 170")
+	s.registerCalculatorMethod("math.combinations", "Combinations", "Number of ways to choose r items from n")
+	s.registerCalculatorMethod("math.fibonacci", "Fibonacci", "n-th Fibonacci number; a string beyond F(78)")
+	s.registerCalculatorMethod("math.isPrime", "IsPrime", "Whether whole number n <= 2^53 is prime")
+	s.registerCalculatorMethod("math.chain", "Chain", "Apply ops to start left to right")
+	s.registerCalculatorMethod("math.baseConvert", "BaseConvert", "Rewrite integer value from base from into base to (2-36)")
+	s.registerCalculatorMethod("math.eval", "Eval", "Evaluate an arithmetic expression with + - * / and parentheses")
+	s.registerCalculatorMethod("math.evalBatch", "EvalBatch", "Evaluate several expressions, reporting errors per expression")
+	s.registerCalculatorMethod("bits.and", "And", "Bitwise AND of integers a and b")
+	s.registerCalculatorMethod("bits.or", "Or", "Bitwise OR of integers a and b")
+	s.registerCalculatorMethod("bits.xor", "Xor", "Bitwise XOR of integers a and b")
+	s.registerCalculatorMethod("bits.not", "Not", "Bitwise complement of integer value")
+	s.registerCalculatorMethod("bits.shiftLeft", "ShiftLeft", "Shift integer a left by b bits (0-63)")
+	s.registerCalculatorMethod("bits.shiftRight", "ShiftRight", "Arithmetic shift of integer a right by b bits (0-63)")
+	s.registerCalculatorMethod("units.convert", "Convert", "Convert value between temperature, length or weight units")
+	s.registerCalculatorMethod("stats.sum", "Sum", "Sum of values")
+	s.registerCalculatorMethod("stats.product", "Product", "Product of values")
+	s.registerCalculatorMethod("stats.min", "Min", "Smallest of values")
+	s.registerCalculatorMethod("stats.max", "Max", "Largest of values")
+	s.registerCalculatorMethod("stats.average", "Average", "Arithmetic mean of values")
+	s.registerCalculatorMethod("stats.median", "Median", "Middle value of values")
+	s.registerCalculatorMethod("stats.variance", "Variance", "Population or sample variance of values")
+	s.registerCalculatorMethod("stats.stddev", "Stddev", "Population or sample standard deviation of values")
+	s.registerCalculatorMethod("math.clamp", "Clamp", "Bound value to [min, max]")
+	s.registerCalculatorMethod("mem.store", "MemStore", "Store a in the memory register")
+	s.registerCalculatorMethod("mem.recall", "MemRecall", "Read the memory register")
+	s.registerCalculatorMethod("mem.clear", "MemClear", "Reset the memory register to 0")
+	s.registerCalculatorMethod("mem.add", "MemAdd", "Add a to the memory register")
+	s.registerCalculatorMethod("mem.undo", "MemUndo", "Revert the memory register's last change")
+	for alias, canonical := range flatMethodNames {
+		s.RegisterAlias(alias, canonical)
+	}
+	s.RegisterAlias("plus", "math.add")
+	s.RegisterAlias("minus", "math.subtract")
+	s.RegisterAlias("times", "math.multiply")
+	s.RegisterAlias("div", "math.divide")
+	s.RegisterMethodWithInfo("getInfo", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return s.calculator.GetInfo(s.methodCatalog())
+	}, MethodInfo{
+		Summary:  "Describe the calculator (superseded by rpc.discover)",
+		Result:   reflect.TypeOf(map[string]interface{}{}),
+		ReadOnly: true,
+		NoParams: true,
+	})
+	s.RegisterMethodWithInfo("ping", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return ping(params)
+	}, MethodInfo{
+		Summary:  "Liveness check: returns \"pong\", echoing an optional payload",
+		Params:   reflect.TypeOf(PingParams{}),
+		ReadOnly: true,
+	})
+	s.RegisterMethodWithInfo("subscribe", s.subscribe, MethodInfo{
+		Summary: "Push the result of a read-only method periodically (WebSocket only); returns a subscription id",
+		Params:  reflect.TypeOf(SubscribeParams{}),
+		Result:  reflect.TypeOf(""),
+	})
+	s.RegisterMethodWithInfo("unsubscribe", s.unsubscribe, MethodInfo{
+		Summary: "Stop a subscription (WebSocket only)",
+		Params:  reflect.TypeOf(UnsubscribeParams{}),
+		Result:  reflect.TypeOf(true),
+	})
+	s.RegisterMethodWithInfo("history", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return s.history.Entries(), nil
+	}, MethodInfo{
+		Summary:  "Recent successful calls, oldest first",
+		Result:   reflect.TypeOf([]HistoryEntry{}),
+		ReadOnly: true,
+		NoParams: true,
+	})
+	s.RegisterMethodWithInfo("clearHistory", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return map[string]int{"cleared": s.history.Clear()}, nil
+	}, MethodInfo{
+		Summary:  "Empty the history buffer",
+		Result:   reflect.TypeOf(map[string]int{}),
+		NoParams: true,
+	})
+	s.RegisterMethodWithInfo("reset", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return s.reset(), nil
+	}, MethodInfo{
+		Summary:  "Clear the memory register, its undo stack, the history and cached idempotent results",
+		Result:   reflect.TypeOf(ResetSummary{}),
+		NoParams: true,
+	})
+	s.RegisterMethodWithInfo("logMessage", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return s.callNotificationMethod("LogMessage", params)
+	}, MethodInfo{
+		Summary:      "Write a message to the server log (notification)",
+		Params:       reflect.TypeOf(LogParams{}),
+		Notification: true,
+	})
+	s.RegisterMethodWithInfo("capabilities", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return s.capabilities(), nil
+	}, MethodInfo{
+		Summary:  "Protocol features, limits and transports of this server, for feature detection",
+		Result:   reflect.TypeOf(Capabilities{}),
+		ReadOnly: true,
+		NoParams: true,
+	})
+	s.RegisterMethodWithInfo("rpc.discover", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return s.openRPCDocument(), nil
+	}, MethodInfo{
+		Summary:  "OpenRPC service description",
+		Result:   reflect.TypeOf(OpenRPCDocument{}),
+		ReadOnly: true,
+		NoParams: true,
+	})
+
+	s.ready.Store(true)
+	return s
+}
+
+// ResetSummary reports what reset cleared
+type ResetSummary struct {
+	Memory          float64 `json:"memory"`          // memory register value before the reset
+	UndoEntries     int     `json:"undoEntries"`     // memory changes that can no longer be undone
+	HistoryEntries  int     `json:"historyEntries"`  // history entries removed
+	IdempotencyKeys int     `json:"idempotencyKeys"` // idempotency keys forgotten
+}
+
+// reset returns the calculator state and history to how a new server starts
+func (s *JSONRPCServer) reset() ResetSummary {
+	memory, undoEntries := s.calculator.reset()
+	summary := ResetSummary{
+		Memory:          memory,
+		UndoEntries:     undoEntries,
+		HistoryEntries:  s.history.Clear(),
+		IdempotencyKeys: s.idempotency.clear(),
+	}
+	s.logger.Info("server state reset", "memory", summary.Memory, "undo_entries", summary.UndoEntries,
+		"history_entries", summary.HistoryEntries, "idempotency_keys", summary.IdempotencyKeys)
+	s.notifyStateChanged("reset")
+	return summary
+}
+
+// Ready reports whether initialization finished and the registry is populated
+func (s *JSONRPCServer) Ready() bool {
+	return s != nil && s.ready.Load() && len(s.methodNames()) > 0
+}
+
+// HandleRequest processes a JSON-RPC request and returns a response
+func (s *JSONRPCServer) HandleRequest(ctx context.Context, data []byte) ([]byte, error) {
+	return s.handleRequest(ctx, data, nil)
+}
+
+// HandleRequestStream is HandleRequest for transports that can send a response
+// while it is being built: a batch response is written to w one element at a
+// time and nil is returned, so memory doesn't grow with the batch. Single
+// requests are returned exactly as from HandleRequest and w is left untouched
+func (s *JSONRPCServer) HandleRequestStream(ctx context.Context, data []byte, w io.Writer) ([]byte, error) {
+	return s.handleRequest(ctx, data, w)
+}
+
+// handleRequest is HandleRequest, streaming batch responses to w when it isn't nil
+func (s *JSONRPCServer) handleRequest(ctx context.Context, data []byte, w io.Writer) ([]byte, error) {
+	// Every log line for this payload carries the same correlation id,
+	// which is unrelated to the JSON-RPC id(s) inside it
+	logger := s.logger.With("correlation_id", newCorrelationID())
+	logger.Debug("received request", "body", string(data))
+	requestsTotal.Inc()
+	timer := prometheus.NewTimer(requestDuration)
+	defer timer.ObserveDuration()
+
+	var stream *countingWriter
+	var out io.Writer // stays a nil interface when not streaming
+	if w != nil {
+		stream = &countingWriter{w: w}
+		out = stream
+	}
+	response, err := s.dispatch(ctx, logger, data, out)
+	if err != nil {
+		logger.Error("failed to build response", "error", err)
+		return nil, err
+	}
+
+	switch {
+	case stream != nil && stream.n > 0:
+		logger.Debug("streamed response", "bytes", stream.n)
+	case response == nil:
+		logger.Debug("no response (notification)")
+	default:
+		logger.Debug("sending response", "bytes", len(response))
+	}
+	return response, nil
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += n
+	return n, err
+}
+
+// dispatch parses a payload and routes it to the single, batch, or notification handler
+// Batch responses go to stream when it isn't nil and are returned otherwise
+func (s *JSONRPCServer) dispatch(ctx context.Context, logger *slog.Logger, data []byte, stream io.Writer) ([]byte, error) {
+	// Parse the incoming message
+	message, err := ParseMessage(data)
+	if err != nil {
+		// Parse error - we can't know the ID, so use null
+		jsonrpcErr := err.(*JSONRPCError)
+		logger.Warn("failed to parse request", "error_code", jsonrpcErr.Code, "error", jsonrpcErr.Data)
+		errorResp := CreateErrorResponse(jsonrpcErr, nil)
+		return json.Marshal(errorResp)
+	}
+
+	// Handle batch vs single requests
+	switch msg := message.(type) {
+	case []interface{}:
+		// Batch request
+		// Refuse oversized batches up front so one payload can't fan out into unbounded work
+		if s.MaxBatchSize > 0 && len(msg) > s.MaxBatchSize {
+			logger.Warn("batch too large", "size", len(msg), "limit", s.MaxBatchSize)
+			return json.Marshal(CreateErrorResponse(&JSONRPCError{
+				Code:    InvalidRequest,
+				Message: "Invalid Request",
+				Data:    fmt.Sprintf("batch too large: %d messages exceeds the limit of %d", len(msg), s.MaxBatchSize),
+			}, nil))
+		}
+		if stream != nil {
+			return nil, s.handleBatchRequest(ctx, logger, msg, stream)
+		}
+		var buf bytes.Buffer
+		if err := s.handleBatchRequest(ctx, logger, msg, &buf); err != nil {
+			return nil, err
+		}
+		if buf.Len() == 0 {
+			return nil, nil
+		}
+		return buf.Bytes(), nil
+	case JSONRPCRequest:
+		// Single request
+		response := s.handleSingleRequest(ctx, logger, msg)
+		return json.Marshal(response)
+	case JSONRPCNotification:
+		// Single notification - no response
+		s.handleNotification(ctx, logger, msg)
+		return nil, nil // No response for notifications
+	default:
+		// This shouldn't happen if parsing worked correctly
+		errorResp := CreateErrorResponse(&JSONRPCError{
+			Code:    InvalidRequest,
+			Message: "Invalid Request",
+			Data:    "Unknown message type",
+		}, nil)
+		return json.Marshal(errorResp)
+	}
+}
+
+// handleBatchRequest processes a batch of requests/notifications
+//
+// Ordering contract: the response array lists one response per request (and
+// per invalid element) in the order those elements appear in the batch, with
+// notifications skipped, no matter which entries finish first. The array is
+// written to w as responses become ready; nothing is written when every entry
+// is a notification
+func (s *JSONRPCServer) handleBatchRequest(ctx context.Context, logger *slog.Logger, messages []interface{}, w io.Writer) error {
+	logger.Debug("handling batch", "size", len(messages))
+
+	messages = rejectDuplicateIDs(messages)
+
+	// An Idempotency-Key header names a single request; batch entries use
+	// the idempotencyKey param instead
+	ctx = withIdempotencyKey(ctx, "")
+
+	// Entries run on a bounded pool of workers; each writes only its own slot
+	// and closes its done channel. Responses are written in request order as
+	// soon as the entries before them are done, and the window keeps workers
+	// from running more than a few entries ahead of the writer
+	workers := min(s.batchWorkers(), len(messages))
+	slots := make([]*JSONRPCResponse, len(messages))
+	done := make([]chan struct{}, len(messages))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	window := make(chan struct{}, 2*workers)
+	indexes := make(chan int)
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := range indexes {
+				switch m := messages[i].(type) {
+				case JSONRPCRequest:
+					// Request - add response to batch
+					response := s.handleSingleRequest(ctx, logger, m)
+					slots[i] = &response
+				case JSONRPCNotification:
+					// Notification - handle but don't add to responses
+					s.handleNotification(ctx, logger, m)
+				case *JSONRPCError:
+					// Invalid element - respond with its error, id unknown
+					response := CreateErrorResponse(m, nil)
+					slots[i] = &response
+				}
+				close(done[i])
+			}
+		}()
+	}
+	go func() {
+		for i := range messages {
+			window <- struct{}{}
+			indexes <- i
+		}
+		close(indexes)
+	}()
+
+	// After a write error the remaining entries still run (they may be
+	// stateful) but nothing more is written
+	var writeErr error
+	written := 0
+	for i := range messages {
+		<-done[i]
+		response := slots[i]
+		slots[i] = nil
+		<-window
+		if response == nil || writeErr != nil {
+			continue
+		}
+
+		data, err := json.Marshal(response)
+		if err != nil {
+			writeErr = err
+			continue
+		}
+		separator := []byte(",")
+		if written == 0 {
+			separator = []byte("[")
+		}
+		if _, err := w.Write(append(separator, data...)); err != nil {
+			writeErr = err
+			continue
+		}
+		written++
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+
+	// If no responses (all were notifications), write nothing
+	if written == 0 {
+		return nil
+	}
+	_, err := w.Write([]byte("]"))
+	return err
+}
+
+// rejectDuplicateIDs replaces every request whose non-null id already appeared
+// earlier in the batch with an Invalid Request error carrying that id.
+// The first occurrence still runs; notifications and null ids are exempt
+func rejectDuplicateIDs(messages []interface{}) []interface{} {
+	seen := make(map[interface{}]bool)
+	checked := make([]interface{}, len(messages))
+	for i, message := range messages {
+		checked[i] = message
+		req, ok := message.(JSONRPCRequest)
+		if !ok || req.ID == nil {
+			continue
+		}
+		// ids are string or json.Number, so "1" and 1 stay distinct keys
+		if seen[req.ID] {
+			checked[i] = &JSONRPCError{
+				Code:    InvalidRequest,
+				Message: "Invalid Request",
+				Data:    map[string]interface{}{"detail": "duplicate id in batch", "id": req.ID},
+			}
+			continue
+		}
+		seen[req.ID] = true
+	}
+	return checked
+}
+
+// batchWorkers returns the number of goroutines used to process a batch
+func (s *JSONRPCServer) batchWorkers() int {
+	if s.BatchWorkers > 0 {
+		return s.BatchWorkers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// handleSingleRequest processes a single JSON-RPC request
+func (s *JSONRPCServer) handleSingleRequest(ctx context.Context, logger *slog.Logger, req JSONRPCRequest) JSONRPCResponse {
+	params, includeTiming, err := splitTimingFlag(req.Params)
+	if err != nil {
+		return CreateErrorResponse(s.clientError(logger, err.(*JSONRPCError)), req.ID)
+	}
+	req.Params = params
+
+	// Route the method call
+	start := time.Now()
+	ctx = withCallInfo(ctx, callInfo{logger: logger, callType: "request", id: req.ID})
+	result, replayed, err := s.callIdempotent(ctx, req.Method, req.Params, func(params interface{}) (interface{}, error) {
+		return s.withMiddleware(s.callRequestMethod)(ctx, req.Method, params)
+	})
+	if replayed {
+		// The call already ran; hooks and history saw it then
+		logger.Info("replayed idempotent request", "method", req.Method, "id", req.ID)
+		return withTiming(CreateSuccessResponse(roundResult(result, s.ResultDigits), req.ID), includeTiming, start)
+	}
+	if err != nil {
+		// Convert regular errors to JSON-RPC errors
+		jsonrpcErr, ok := err.(*JSONRPCError)
+		if !ok {
+			jsonrpcErr = &JSONRPCError{
+				Code:    InternalError,
+				Message: "Internal error",
+				Data:    err.Error(),
+			}
+		}
+		s.runCallHooks(req.Method, req.Params, nil, jsonrpcErr, start)
+		return CreateErrorResponse(s.clientError(logger, jsonrpcErr), req.ID)
+	}
+
+	result = roundResult(result, s.ResultDigits)
+	s.runCallHooks(req.Method, req.Params, result, nil, start)
+	s.recordHistory(req.Method, req.Params, result, "request")
+	return withTiming(CreateSuccessResponse(result, req.ID), includeTiming, start)
+}
+
+// splitTimingFlag removes an optional boolean "includeTiming" member from named params
+func splitTimingFlag(params interface{}) (interface{}, bool, error) {
+	fields, ok := params.(map[string]interface{})
+	if !ok {
+		return params, false, nil
+	}
+	raw, ok := fields["includeTiming"]
+	if !ok {
+		return params, false, nil
+	}
+
+	includeTiming, ok := raw.(bool)
+	if !ok {
+		return nil, false, invalidParams("includeTiming", reasonType, "boolean", "Parameter 'includeTiming' must be a boolean")
+	}
+
+	stripped := make(map[string]interface{}, len(fields)-1)
+	for name, value := range fields {
+		if name != "includeTiming" {
+			stripped[name] = value
+		}
+	}
+	return stripped, includeTiming, nil
+}
+
+// withTiming adds the time since start to a successful response when requested
+func withTiming(response JSONRPCResponse, includeTiming bool, start time.Time) JSONRPCResponse {
+	if includeTiming {
+		response.Timing = &Timing{MS: float64(time.Since(start).Microseconds()) / 1000}
+	}
+	return response
+}
+
+// roundResult rounds the float values of a result to digits significant digits
+// Non-numeric results and digits <= 0 are returned unchanged
+func roundResult(result interface{}, digits int) interface{} {
+	if digits <= 0 {
+		return result
+	}
+
+	switch v := result.(type) {
+	case float64:
+		rounded, _ := strconv.ParseFloat(strconv.FormatFloat(v, 'g', digits, 64), 64)
+		return rounded
+	case map[string]float64:
+		rounded := make(map[string]float64, len(v))
+		for key, f := range v {
+			rounded[key] = roundResult(f, digits).(float64)
+		}
+		return rounded
+	case ResultEnvelope:
+		v.Value = roundResult(v.Value, digits)
+		return v
+	case []EvalResult:
+		rounded := make([]EvalResult, len(v))
+		for i, r := range v {
+			rounded[i] = r
+			if r.Result != nil {
+				value := roundResult(*r.Result, digits).(float64)
+				rounded[i].Result = &value
+			}
+		}
+		return rounded
+	}
+	return result
+}
+
+// clientError returns the error as it should be shown to the client
+// Unless VerboseErrors is set, Internal error details are logged and withheld.
+// Invalid params Data is sent as InvalidParamsDetail or as its message,
+// depending on StructuredParamErrors
+func (s *JSONRPCServer) clientError(logger *slog.Logger, jsonrpcErr *JSONRPCError) *JSONRPCError {
+	if jsonrpcErr.Code == InvalidParams {
+		return &JSONRPCError{
+			Code:    jsonrpcErr.Code,
+			Message: jsonrpcErr.Message,
+			Data:    paramsErrorData(jsonrpcErr.Data, s.StructuredParamErrors),
+		}
+	}
+	if s.VerboseErrors || jsonrpcErr.Code != InternalError || jsonrpcErr.Data == nil {
+		return jsonrpcErr
+	}
+
+	logger.Error("internal error details withheld from client", "data", jsonrpcErr.Data)
+	return &JSONRPCError{
+		Code:    jsonrpcErr.Code,
+		Message: jsonrpcErr.Message,
+	}
+}
+
+// callRequestMethod calls a method on behalf of a request that expects a result
+// Notification-only methods are rejected since they never produce one
+func (s *JSONRPCServer) callRequestMethod(ctx context.Context, method string, params interface{}) (interface{}, error) {
+	if s.isNotificationOnly(method) {
+		return nil, &JSONRPCError{
+			Code:    MethodNotFound,
+			Message: "Method not found",
+			Data:    fmt.Sprintf("Method '%s' is notification-only; send it without an id", method),
+		}
+	}
+
+	params, validate, err := splitValidateFlag(params)
+	if err != nil {
+		return nil, err
+	}
+	if validate {
+		if err := s.validateMethod(method, params); err != nil {
+			return nil, err
+		}
+		return map[string]bool{"valid": true}, nil
+	}
+	return s.callMethodWithTimeout(ctx, method, params)
+}
+
+// splitValidateFlag removes an optional boolean "validate" member from named params
+func splitValidateFlag(params interface{}) (interface{}, bool, error) {
+	fields, ok := params.(map[string]interface{})
+	if !ok {
+		return params, false, nil
+	}
+	raw, ok := fields["validate"]
+	if !ok {
+		return params, false, nil
+	}
+
+	validate, ok := raw.(bool)
+	if !ok {
+		return nil, false, invalidParams("validate", reasonType, "boolean", "Parameter 'validate' must be a boolean")
+	}
+
+	stripped := make(map[string]interface{}, len(fields)-1)
+	for key, value := range fields {
+		if key != "validate" {
+			stripped[key] = value
+		}
+	}
+	return stripped, validate, nil
+}
+
+// handleNotification processes a notification (no response)
+func (s *JSONRPCServer) handleNotification(ctx context.Context, logger *slog.Logger, notif JSONRPCNotification) {
+	// Call method but ignore any result/error since it's a notification
+	start := time.Now()
+	ctx = withCallInfo(ctx, callInfo{logger: logger, callType: "notification"})
+	result, err := s.callMethod(ctx, notif.Method, notif.Params)
+	s.runCallHooks(notif.Method, notif.Params, result, err, start)
+	if err != nil {
+		return
+	}
+
+	s.recordHistory(notif.Method, notif.Params, result, "notification")
+}
+
+// logCall logs the outcome of a dispatched call with structured attributes
+func logCall(logger *slog.Logger, msg string, method string, id interface{}, start time.Time, err error) {
+	attrs := []any{
+		"method", method,
+		"duration_ms", float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if id != nil {
+		attrs = append(attrs, "id", id)
+	}
+
+	if err != nil {
+		code := InternalError
+		if jsonrpcErr, ok := err.(*JSONRPCError); ok {
+			code = jsonrpcErr.Code
+		}
+		logger.Warn(msg, append(attrs, "error_code", code, "error", err.Error())...)
+		return
+	}
+
+	logger.Info(msg, attrs...)
+}
+
+// newCorrelationID returns a random (version 4) UUID for correlating log lines
+func newCorrelationID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// recordHistory adds a successful call to the history buffer
+// Calls to the history methods themselves are not recorded
+func (s *JSONRPCServer) recordHistory(method string, params interface{}, result interface{}, callType string) {
+	if strings.EqualFold(method, "history") || strings.EqualFold(method, "clearHistory") || strings.EqualFold(method, "reset") {
+		return
+	}
+
+	s.history.Add(HistoryEntry{
+		Method:    method,
+		Params:    params,
+		Result:    result,
+		Type:      callType,
+		Timestamp: time.Now(),
+	})
+}
+
+// requestTimeoutKey is the context key under which a per-request timeout override is stored
+type requestTimeoutKey struct{}
+
+// withRequestTimeout asks for method calls made with ctx to use timeout
+// instead of RequestTimeout, up to MaxRequestTimeout
+func withRequestTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, requestTimeoutKey{}, timeout)
+}
+
+// requestTimeout returns the timeout for a call made with ctx: the override
+// from withRequestTimeout capped at MaxRequestTimeout, or RequestTimeout
+func (s *JSONRPCServer) requestTimeout(ctx context.Context) time.Duration {
+	timeout, ok := ctx.Value(requestTimeoutKey{}).(time.Duration)
+	if !ok || timeout <= 0 {
+		return s.RequestTimeout
+	}
+	if s.MaxRequestTimeout > 0 && timeout > s.MaxRequestTimeout {
+		return s.MaxRequestTimeout
+	}
+	return timeout
+}
+
+// callMethod calls a method through the middleware pipeline (see Use) under the
+// request's timeout
+func (s *JSONRPCServer) callMethod(ctx context.Context, method string, params interface{}) (interface{}, error) {
+	return s.withMiddleware(s.callMethodWithTimeout)(ctx, method, params)
+}
+
+// callMethodWithTimeout runs invokeMethod under the request's timeout and
+// returns a RequestTimeout error as soon as the deadline passes, even if the
+// method itself doesn't honor ctx
+func (s *JSONRPCServer) callMethodWithTimeout(ctx context.Context, method string, params interface{}) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout(ctx))
+	defer cancel()
+
+	type callResult struct {
+		result interface{}
+		err    error
+	}
+	done := make(chan callResult, 1) // buffered so a late method doesn't leak its goroutine
+	go func() {
+		var res callResult
+		defer func() { done <- res }()
+		defer s.recoverPanic(method, &res.err)
+		res.result, res.err = s.invokeMethod(ctx, method, params)
+	}()
+
+	select {
+	case res := <-done:
+		return res.result, res.err
+	case <-ctx.Done():
+		return nil, contextError(ctx)
+	}
+}
+
+// invokeMethod dispatches method calls through the method registry
+func (s *JSONRPCServer) invokeMethod(ctx context.Context, method string, params interface{}) (interface{}, error) {
+	entry, ok := s.lookupMethod(method)
+	if !ok {
+		return nil, s.methodNotFoundError(method)
+	}
+
+	// Handlers receive params in their raw form
+	var rawParams json.RawMessage
+	if params != nil {
+		paramBytes, err := json.Marshal(params)
+		if err != nil {
+			return nil, invalidParams("", reasonInvalid, "", "Cannot marshal parameters")
+		}
+		rawParams = paramBytes
+	}
+
+	if s.LenientDefaults {
+		rawParams = withDefaults(rawParams, entry.info.Defaults)
+	}
+	if entry.info.NoParams && hasParams(rawParams) {
+		return nil, noParamsError()
+	}
+
+	// Don't start work for a request that has already been cancelled
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	return entry.handler(ctx, rawParams)
+}
+
+// registerCalculatorMethod registers a calculator method under name, describing
+// its params and result from the method's signature
+func (s *JSONRPCServer) registerCalculatorMethod(name string, methodName string, summary string) {
+	info := MethodInfo{Summary: summary, ReadOnly: !statefulMethods[methodName], Defaults: operandDefaults[name]}
+	info.Validate = func(params json.RawMessage) error {
+		_, err := s.prepareCalculatorCall(methodName, params)
+		return err
+	}
+	if method, ok := reflect.TypeOf(s.calculator).MethodByName(methodName); ok {
+		// Method types include the receiver as the first input
+		if method.Type.NumIn() == 2 {
+			info.Params = method.Type.In(1)
+		}
+		if method.Type.NumOut() > 0 {
+			info.Result = method.Type.Out(0)
+		}
+	}
+
+	s.RegisterMethodWithInfo(name, func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		result, err := s.callCalculatorMethod(name, methodName, params)
+		if err == nil && statefulMethods[methodName] {
+			s.notifyStateChanged(name)
+		}
+		return result, err
+	}, info)
+}
+
+// exprFunction resolves a function called in an expression through the method
+// registry, so any registered method taking a single value or a list of values
+// is callable by its name or an alias, e.g. sqrt(16) or math.sqrt(16)
+func (s *JSONRPCServer) exprFunction(name string) (exprFunc, bool) {
+	entry, ok := s.lookupMethod(name)
+	if !ok || !exprParamTypes[entry.info.Params] {
+		return nil, false
+	}
+
+	return func(args []float64) (float64, error) {
+		params, err := exprFunctionParams(entry.info.Params, args)
+		if err != nil {
+			return 0, err
+		}
+		rawParams, err := json.Marshal(params)
+		if err != nil {
+			return 0, err
+		}
+		result, err := entry.handler(context.Background(), rawParams)
+		if err != nil {
+			return 0, err
+		}
+		value, ok := result.(float64)
+		if !ok {
+			return 0, &JSONRPCError{
+				Code:    InternalError,
+				Message: "Internal error",
+				Data:    fmt.Sprintf("Method %s did not return a number", name),
+			}
+		}
+		return value, nil
+	}, true
+}
+
+// contextError converts a cancelled or expired context into a JSON-RPC error
+func contextError(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &JSONRPCError{
+			Code:    RequestTimeout,
+			Message: "Request timeout",
+			Data:    "The method did not complete before the request deadline",
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return &JSONRPCError{
+			Code:    -32000, // Application error
+			Message: "Request cancelled",
+			Data:    err.Error(),
+		}
+	}
+	return nil
+}
+
+// calculatorCall is a calculator method call whose params are decoded and validated
+type calculatorCall struct {
+	method  reflect.Value
+	args    []reflect.Value // empty for methods without parameters
+	verbose bool
+	format  *NumberFormat
+}
+
+// callCalculatorMethod calls a calculator method, unmarshalling params into the
+// method's parameter type (e.g. CalculatorParams or UnaryParams)
+// name is the JSON-RPC method name, reported as the operation in verbose results
+func (s *JSONRPCServer) callCalculatorMethod(name string, methodName string, params json.RawMessage) (interface{}, error) {
+	call, err := s.prepareCalculatorCall(methodName, params)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := callResults(call.method.Call(call.args))
+	if err != nil {
+		return nil, err
+	}
+	if call.verbose {
+		var paramValue reflect.Value
+		if len(call.args) > 0 {
+			paramValue = call.args[0]
+		}
+		result = newResultEnvelope(name, paramValue, result)
+	}
+	return s.applyFormat(result, call.format), nil
+}
+
+// prepareCalculatorCall decodes and validates params for a calculator method
+// without calling it, so validate-only requests share the exact same checks
+func (s *JSONRPCServer) prepareCalculatorCall(methodName string, params json.RawMessage) (*calculatorCall, error) {
+	// Use reflection to find the method
+	calcValue := reflect.ValueOf(s.calculator)
+	method := calcValue.MethodByName(methodName)
+	if !method.IsValid() || method.Type().NumIn() > 1 {
+		return nil, &JSONRPCError{
+			Code:    InternalError,
+			Message: "Internal error",
+			Data:    fmt.Sprintf("Method %s not found on calculator", methodName),
+		}
+	}
+
+	// "verbose" and "format" select the result shape, so remove them before strict decoding
+	params, verbose, err := splitVerboseFlag(params)
+	if err != nil {
+		return nil, err
+	}
+	params, format, err := splitFormatOption(params)
+	if err != nil {
+		return nil, err
+	}
+	call := &calculatorCall{method: method, verbose: verbose, format: format}
+
+	// Methods without parameters (e.g. MemRecall) are called directly
+	if method.Type().NumIn() == 0 {
+		if hasParams(params) {
+			return nil, noParamsError()
+		}
+		return call, nil
+	}
+
+	// Map positional params ([a, b]) onto named fields for binary operations
+	if len(params) > 0 && params[0] == '[' && method.Type().In(0) == reflect.TypeOf(CalculatorParams{}) {
+		named, err := positionalCalculatorParams(params)
+		if err != nil {
+			return nil, err
+		}
+		params = named
+	}
+
+	// Parse parameters into the type the method expects
+	paramValue := reflect.New(method.Type().In(0))
+	if err := decodeParams(params, paramValue.Interface(), paramsUsage(method.Type().In(0))); err != nil {
+		return nil, err
+	}
+
+	// Let parameter types check constraints the JSON decoder can't express
+	if v, ok := paramValue.Interface().(paramsValidator); ok {
+		if err := v.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	call.args = []reflect.Value{paramValue.Elem()}
+	return call, nil
+}
+
+// applyFormat renders a result's numbers as strings when a format was requested,
+// rounding first so ResultDigits still applies
+func (s *JSONRPCServer) applyFormat(result interface{}, format *NumberFormat) interface{} {
+	if format == nil {
+		return result
+	}
+	return formatResult(roundResult(result, s.ResultDigits), *format)
+}
+
+// ResultEnvelope is the result of a calculator method called with "verbose": true
+type ResultEnvelope struct {
+	Value     interface{}   `json:"value"`
+	Operation string        `json:"operation"`
+	Operands  []interface{} `json:"operands"`
+}
+
+// newResultEnvelope wraps a calculator result with the operation name and the
+// operands taken from its decoded params struct (in field order, lists flattened)
+func newResultEnvelope(operation string, params reflect.Value, value interface{}) ResultEnvelope {
+	operands := []interface{}{}
+	if params.IsValid() {
+		for i := 0; i < params.NumField(); i++ {
+			field := params.Field(i)
+			switch field.Kind() {
+			case reflect.Bool:
+				// Flags such as "degrees" modify the operation rather than feed it
+			case reflect.Pointer:
+				if !field.IsNil() {
+					operands = append(operands, field.Elem().Interface())
+				}
+			case reflect.Slice:
+				for j := 0; j < field.Len(); j++ {
+					operands = append(operands, field.Index(j).Interface())
+				}
+			default:
+				operands = append(operands, field.Interface())
+			}
+		}
+	}
+
+	return ResultEnvelope{Value: value, Operation: operation, Operands: operands}
+}
+
+// splitVerboseFlag removes an optional boolean "verbose" member from named params
+func splitVerboseFlag(params json.RawMessage) (json.RawMessage, bool, error) {
+	if len(params) == 0 || params[0] != '{' {
+		return params, false, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(params, &fields); err != nil {
+		// Leave malformed params for decodeParams to report
+		return params, false, nil
+	}
+	raw, ok := fields["verbose"]
+	if !ok {
+		return params, false, nil
+	}
+
+	var verbose bool
+	if err := json.Unmarshal(raw, &verbose); err != nil {
+		return nil, false, invalidParams("verbose", reasonType, "boolean", "Parameter 'verbose' must be a boolean")
+	}
+	delete(fields, "verbose")
+
+	stripped, err := json.Marshal(fields)
+	if err != nil {
+		return nil, false, err
+	}
+	return stripped, verbose, nil
+}
+
+// callResults unpacks the (result, error) values returned by a calculator method
+func callResults(results []reflect.Value) (interface{}, error) {
+	// Handle results (expecting result, error pattern)
+	if len(results) != 2 {
+		return nil, &JSONRPCError{
+			Code:    InternalError,
+			Message: "Internal error",
+			Data:    "Unexpected return value count",
+		}
+	}
+
+	// Check for error
+	if !results[1].IsNil() {
+		err := results[1].Interface().(error)
+		return nil, err
+	}
+
+	// NaN and ±Inf can't be encoded as JSON, so catch any a method let through
+	result := results[0].Interface()
+	switch v := result.(type) {
+	case float64:
+		if err := checkFinite(v); err != nil {
+			return nil, err
+		}
+	case map[string]float64:
+		for _, f := range v {
+			if err := checkFinite(f); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Return the result
+	return result, nil
+}
+
+// positionalCalculatorParams converts a [a, b] params array into {"a": a, "b": b}
+func positionalCalculatorParams(params json.RawMessage) (json.RawMessage, error) {
+	var args []json.RawMessage
+	if err := json.Unmarshal(params, &args); err != nil || len(args) < 2 {
+		return nil, invalidParams("", reasonRequired, "[a, b]", fmt.Sprintf("Expected 2 positional parameters [a, b], got %d", len(args)))
+	}
+
+	for i, arg := range args[:2] {
+		var n lenientNumber
+		if err := json.Unmarshal(arg, &n); err != nil {
+			return nil, invalidParams([]string{"a", "b"}[i], reasonType, "number or numeric string", fmt.Sprintf("Positional parameter %d must be a number or numeric string", i))
+		}
+	}
+
+	return json.Marshal(map[string]json.RawMessage{"a": args[0], "b": args[1]})
+}
+
+// noParamsError rejects params sent to a method that takes none
+func noParamsError() error {
+	return invalidParams("", reasonUnexpected, "no parameters", "this method takes no parameters")
+}
+
+// hasParams reports whether raw params carry anything; absent, null, {} and []
+// are all treated as no params
+func hasParams(params json.RawMessage) bool {
+	switch string(bytes.TrimSpace(params)) {
+	case "", "null", "{}", "[]":
+		return false
+	}
+	return true
+}
+
+// paramsValidator is implemented by parameter types that need validation after decoding
+type paramsValidator interface {
+	Validate() error
+}
+
+// decodeParams unmarshals raw params into target, using usage to describe the
+// expected shape in error data
+func decodeParams(params json.RawMessage, target interface{}, usage string) error {
+	if params == nil {
+		return invalidParams("", reasonRequired, usage, "Parameters required: "+usage)
+	}
+
+	// Reject unknown fields so typos don't silently compute with zero values
+	decoder := json.NewDecoder(bytes.NewReader(params))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(target); err != nil {
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			return invalidParams(strings.Trim(field, `"`), reasonUnknown, usage, fmt.Sprintf("Unknown parameter %s; expected parameters: %s", field, usage))
+		}
+
+		// Type mismatches name the offending field and the JSON type it needs
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			expected, _ := jsonSchema(typeErr.Type)["type"].(string)
+			return invalidParams(typeErr.Field, reasonType, expected, "Expected parameters: "+usage)
+		}
+		return invalidParams("", reasonType, usage, "Expected parameters: "+usage)
+	}
+
+	return checkRequiredParams(params, target)
+}
+
+// checkRequiredParams ensures every field of target without an omitempty tag was
+// present in params, so a missing operand isn't mistaken for an explicit 0
+func checkRequiredParams(params json.RawMessage, target interface{}) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(params, &fields); err != nil {
+		return nil
+	}
+
+	t := reflect.TypeOf(target).Elem()
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		name, opts, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" || strings.Contains(opts, "omitempty") {
+			continue
+		}
+
+		if _, present := fields[name]; !present {
+			expected, _ := jsonSchema(t.Field(i).Type)["type"].(string)
+			return invalidParams(name, reasonRequired, expected, fmt.Sprintf("parameter '%s' is required", name))
+		}
+	}
+
+	return nil
+}
+
+// paramsUsage describes the JSON shape of a calculator parameter type
+func paramsUsage(t reflect.Type) string {
+	switch t {
+	case reflect.TypeOf(CalculatorParams{}):
+		return `{"a": number, "b": number}`
+	case reflect.TypeOf(DecimalParams{}):
+		return `{"a": decimal string, "b": decimal string}`
+	case reflect.TypeOf(BigParams{}):
+		return `{"a": integer string, "b": integer string}`
+	case reflect.TypeOf(IntParams{}):
+		return `{"a": 64-bit integer, "b": 64-bit integer}`
+	case reflect.TypeOf(IntUnaryParams{}):
+		return `{"value": 64-bit integer}`
+	case reflect.TypeOf(ShiftParams{}):
+		return `{"a": 64-bit integer, "b": integer 0-63}`
+	case reflect.TypeOf(UnaryParams{}):
+		return `{"value": number}`
+	case reflect.TypeOf(RoundParams{}):
+		return `{"value": number, "digits": integer (optional)}`
+	case reflect.TypeOf(AngleParams{}):
+		return `{"value": number, "degrees": boolean (optional)}`
+	case reflect.TypeOf(VariadicParams{}):
+		return `{"values": [number, ...]}`
+	case reflect.TypeOf(DispersionParams{}):
+		return `{"values": [number, ...], "sample": boolean (optional)}`
+	case reflect.TypeOf(ClampParams{}):
+		return `{"value": number, "min": number, "max": number}`
+	case reflect.TypeOf(FactorialParams{}):
+		return `{"n": non-negative integer}`
+	case reflect.TypeOf(CombinationsParams{}):
+		return `{"n": non-negative integer, "r": non-negative integer}`
+	case reflect.TypeOf(FibonacciParams{}):
+		return `{"n": non-negative integer}`
+	case reflect.TypeOf(PrimeParams{}):
+		return `{"n": non-negative integer <= 2^53}`
+	case reflect.TypeOf(ChainParams{}):
+		return `{"start": number, "ops": [{"op": "add"|"subtract"|"multiply"|"divide"|"power"|"modulo", "value": number}, ...]}`
+	case reflect.TypeOf(BaseConvertParams{}):
+		return `{"value": string, "from": integer 2-36, "to": integer 2-36}`
+	case reflect.TypeOf(ConvertParams{}):
+		return `{"value": number, "from": unit, "to": unit}`
+	case reflect.TypeOf(EvalParams{}):
+		return `{"expr": string, "vars": {name: number} (optional)}`
+	case reflect.TypeOf(EvalBatchParams{}):
+		return `{"exprs": [string, ...]}`
+	case reflect.TypeOf(MemoryParams{}):
+		return `{"a": number}`
+	case reflect.TypeOf(LogarithmParams{}):
+		return `{"value": number, "base": number (optional, default e)}`
+	case reflect.TypeOf(PingParams{}):
+		return `{"payload": any (optional)}`
+	case reflect.TypeOf(SubscribeParams{}):
+		return `{"method": string, "params": any (optional), "intervalMs": integer >= 100 (optional, default 1000)}`
+	case reflect.TypeOf(UnsubscribeParams{}):
+		return `{"subscription": string}`
+	case reflect.TypeOf(LogParams{}):
+		return `{"message": string}`
+	default:
+		return t.Name()
+	}
+}
+
+// callNotificationMethod calls a method for notifications (no return value expected)
+func (s *JSONRPCServer) callNotificationMethod(methodName string, params json.RawMessage) (interface{}, error) {
+	switch methodName {
+	case "LogMessage":
+		var logParams LogParams
+		if err := decodeParams(params, &logParams, paramsUsage(reflect.TypeOf(logParams))); err != nil {
+			return nil, err
+		}
+
+		s.calculator.LogMessage(logParams)
+		return nil, nil // No return value for notifications
+	}
+
+	return nil, &JSONRPCError{
+		Code:    MethodNotFound,
+		Message: "Method not found",
+		Data:    fmt.Sprintf("Notification method '%s' is not available", methodName),
+	}
+}
@@ -0,0 +1,225 @@
+package calcrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testResponse is a decoded response; the id stays raw JSON so string and
+// null ids can be checked as sent
+type testResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *JSONRPCError   `json:"error"`
+	ID     json.RawMessage `json:"id"`
+}
+
+// newTestServer returns a server for tests that discards its logs
+func newTestServer() *JSONRPCServer {
+	return NewJSONRPCServer(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// call sends body to s and returns the raw response
+func call(t *testing.T, s *JSONRPCServer, body string) []byte {
+	t.Helper()
+	out, err := s.HandleRequest(context.Background(), []byte(body))
+	if err != nil {
+		t.Fatalf("HandleRequest(%s): %v", body, err)
+	}
+	return out
+}
+
+// callResponse sends a single request to s and decodes its response
+func callResponse(t *testing.T, s *JSONRPCServer, body string) testResponse {
+	t.Helper()
+	var response testResponse
+	if err := json.Unmarshal(call(t, s, body), &response); err != nil {
+		t.Fatalf("decoding response to %s: %v", body, err)
+	}
+	return response
+}
+
+// callError sends body to s and returns the error code of its response, or 0 on success
+func callError(t *testing.T, s *JSONRPCServer, body string) int {
+	t.Helper()
+	response := callResponse(t, s, body)
+	if response.Error == nil {
+		return 0
+	}
+	return response.Error.Code
+}
+
+// decodeInto decodes the result of a successful response into v
+func decodeInto(t *testing.T, response testResponse, v interface{}) {
+	t.Helper()
+	if response.Error != nil {
+		t.Fatalf("unexpected error %d %s: %v", response.Error.Code, response.Error.Message, response.Error.Data)
+	}
+	if err := json.Unmarshal(response.Result, v); err != nil {
+		t.Fatalf("decoding result %s: %v", response.Result, err)
+	}
+}
+
+func TestUnknownParamField(t *testing.T) {
+	s := newTestServer()
+
+	response := callResponse(t, s, `{"jsonrpc":"2.0","method":"add","params":{"aa":1,"b":2},"id":1}`)
+	if response.Error == nil || response.Error.Code != InvalidParams {
+		t.Fatalf("error = %v, want InvalidParams", response.Error)
+	}
+	if data, _ := response.Error.Data.(string); !strings.Contains(data, `"aa"`) {
+		t.Errorf("data = %v, want it to name \"aa\"", response.Error.Data)
+	}
+
+	response = callResponse(t, s, `{"jsonrpc":"2.0","method":"add","params":{"a":1,"b":2},"id":2}`)
+	var result float64
+	decodeInto(t, response, &result)
+	if result != 3 {
+		t.Errorf("add = %v, want 3", result)
+	}
+}
+
+func TestBatchWithInvalidEntries(t *testing.T) {
+	s := newTestServer()
+	body := `[
+		{"jsonrpc":"2.0","method":"add","params":[1,2],"id":1},
+		{"jsonrpc":"2.0","method":"add","params":[1,2]},
+		{"garbage":true},
+		42,
+		{"jsonrpc":"2.0","method":"subtract","params":[5,2],"id":"x"}
+	]`
+	var responses []testResponse
+	if err := json.Unmarshal(call(t, s, body), &responses); err != nil {
+		t.Fatal(err)
+	}
+
+	// The notification gets no response; each invalid entry gets its own
+	want := []struct {
+		id     string
+		result string
+		code   int
+	}{
+		{`1`, `3`, 0},
+		{`null`, ``, InvalidRequest},
+		{`null`, ``, InvalidRequest},
+		{`"x"`, `3`, 0},
+	}
+	if len(responses) != len(want) {
+		t.Fatalf("got %d responses, want %d", len(responses), len(want))
+	}
+	for i, response := range responses {
+		code := 0
+		if response.Error != nil {
+			code = response.Error.Code
+		}
+		if string(response.ID) != want[i].id || string(response.Result) != want[i].result || code != want[i].code {
+			t.Errorf("response %d = id %s result %s code %d, want id %s result %s code %d",
+				i, response.ID, response.Result, code, want[i].id, want[i].result, want[i].code)
+		}
+	}
+}
+
+func TestSlowMethodTimesOut(t *testing.T) {
+	s := newTestServer()
+	s.RequestTimeout = 20 * time.Millisecond
+	s.RegisterMethod("slow", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		select {
+		case <-time.After(time.Second):
+			return "done", nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	})
+
+	start := time.Now()
+	response := callResponse(t, s, `{"jsonrpc":"2.0","method":"slow","id":1}`)
+	if response.Error == nil || response.Error.Code != RequestTimeout || response.Error.Message != "Request timeout" {
+		t.Fatalf("error = %v, want RequestTimeout", response.Error)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("timed out after %v, want about %v", elapsed, s.RequestTimeout)
+	}
+}
+
+func TestBatchResponsesFollowRequestOrder(t *testing.T) {
+	s := newTestServer()
+	s.BatchWorkers = 4
+	s.RegisterMethod("slow", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		time.Sleep(50 * time.Millisecond)
+		return "slow", nil
+	})
+	s.RegisterMethod("fast", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return "fast", nil
+	})
+
+	// Slow calls come first, so the fast ones finish before them
+	body := `[
+		{"jsonrpc":"2.0","method":"slow","id":1},
+		{"jsonrpc":"2.0","method":"fast","id":2},
+		{"jsonrpc":"2.0","method":"slow"},
+		{"jsonrpc":"2.0","method":"slow","id":3},
+		{"jsonrpc":"2.0","method":"fast","id":4},
+		{"jsonrpc":"2.0","method":"fast","id":5}
+	]`
+	var responses []testResponse
+	if err := json.Unmarshal(call(t, s, body), &responses); err != nil {
+		t.Fatal(err)
+	}
+
+	wantIDs := []string{"1", "2", "3", "4", "5"}
+	wantResults := []string{"slow", "fast", "slow", "fast", "fast"}
+	if len(responses) != len(wantIDs) {
+		t.Fatalf("got %d responses, want %d", len(responses), len(wantIDs))
+	}
+	for i, response := range responses {
+		var result string
+		decodeInto(t, response, &result)
+		if string(response.ID) != wantIDs[i] || result != wantResults[i] {
+			t.Errorf("response %d = id %s %q, want id %s %q", i, response.ID, result, wantIDs[i], wantResults[i])
+		}
+	}
+}
+
+// BenchmarkBatch compares sequential batch processing (one worker) with the
+// worker pool, using a method that waits like a call doing I/O
+func BenchmarkBatch(b *testing.B) {
+	const batchSize = 64
+	var body strings.Builder
+	body.WriteString("[")
+	for i := 0; i < batchSize; i++ {
+		if i > 0 {
+			body.WriteString(",")
+		}
+		fmt.Fprintf(&body, `{"jsonrpc":"2.0","method":"wait","id":%d}`, i)
+	}
+	body.WriteString("]")
+	payload := []byte(body.String())
+
+	workerCounts := []int{1, 16}
+	if procs := runtime.GOMAXPROCS(0); procs > 1 && procs != 16 {
+		workerCounts = []int{1, procs, 16}
+	}
+	for _, workers := range workerCounts {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			s := newTestServer()
+			s.BatchWorkers = workers
+			s.RegisterMethod("wait", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+				time.Sleep(time.Millisecond)
+				return nil, nil
+			})
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := s.HandleRequest(context.Background(), payload); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
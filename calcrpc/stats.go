@@ -0,0 +1,84 @@
+package calcrpc
+
+import (
+	"math"
+	"sort"
+)
+
+// DispersionParams represents parameters for variance and stddev
+// Sample selects the sample (n-1) rather than the population (n) denominator
+type DispersionParams struct {
+	Values []float64 `json:"values"`
+	Sample bool      `json:"sample,omitempty"`
+}
+
+// Validate ensures the values field was supplied
+func (p *DispersionParams) Validate() error {
+	if p.Values == nil {
+		return invalidParams("values", reasonRequired, "array of numbers", "Parameter 'values' is required and must be an array of numbers")
+	}
+	return nil
+}
+
+// variance returns the population or sample variance of a non-empty list
+// A single value has no spread, so its variance is 0 either way
+func variance(values []float64, sample bool) float64 {
+	n := float64(len(values))
+	if n < 2 {
+		return 0
+	}
+
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= n
+
+	squares := 0.0
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	if sample {
+		return squares / (n - 1)
+	}
+	return squares / n
+}
+
+// Variance returns the variance of the values
+func (c *Calculator) Variance(params DispersionParams) (float64, error) {
+	if err := nonEmptyValues("variance", params.Values); err != nil {
+		return 0, err
+	}
+
+	result := variance(params.Values, params.Sample)
+	c.logger.Debug("calculation", "operation", "variance", "values", params.Values, "sample", params.Sample, "result", result)
+	return result, nil
+}
+
+// Stddev returns the standard deviation of the values
+func (c *Calculator) Stddev(params DispersionParams) (float64, error) {
+	if err := nonEmptyValues("stddev", params.Values); err != nil {
+		return 0, err
+	}
+
+	result := math.Sqrt(variance(params.Values, params.Sample))
+	c.logger.Debug("calculation", "operation", "stddev", "values", params.Values, "sample", params.Sample, "result", result)
+	return result, nil
+}
+
+// Median returns the middle value, or the mean of the two middle values for an even count
+func (c *Calculator) Median(params VariadicParams) (float64, error) {
+	if err := nonEmptyValues("median", params.Values); err != nil {
+		return 0, err
+	}
+
+	sorted := append([]float64(nil), params.Values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	result := sorted[mid]
+	if len(sorted)%2 == 0 {
+		result = (sorted[mid-1] + sorted[mid]) / 2
+	}
+	c.logger.Debug("calculation", "operation", "median", "values", params.Values, "result", result)
+	return result, nil
+}
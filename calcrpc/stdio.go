@@ -0,0 +1,30 @@
+package calcrpc
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+// StdioTransport serves newline-delimited JSON-RPC messages on a pair of
+// streams, stdin/stdout by default
+// Logs go to stderr, so stdout carries only protocol messages
+type StdioTransport struct {
+	In  io.Reader // nil reads os.Stdin
+	Out io.Writer // nil writes os.Stdout
+}
+
+// Serve reads messages until In is exhausted
+func (t *StdioTransport) Serve(ctx context.Context, rpcServer *JSONRPCServer) error {
+	in, out := t.In, t.Out
+	if in == nil {
+		in = os.Stdin
+	}
+	if out == nil {
+		out = os.Stdout
+	}
+
+	rpcServer.addTransport(false, "stdio")
+	rpcServer.logger.Info("JSON-RPC Calculator serving on stdin/stdout")
+	return rpcServer.serveStream(ctx, in, out)
+}
@@ -0,0 +1,45 @@
+package calcrpc
+
+import (
+	"bufio"
+	"context"
+	"io"
+)
+
+// maxLineSize bounds a single newline-delimited message on stream transports
+const maxLineSize = 1024 * 1024
+
+// serveStream reads newline-delimited JSON-RPC messages from r and writes one
+// response per line to w until r is exhausted
+// Malformed lines get a ParseError response and processing continues
+func (s *JSONRPCServer) serveStream(ctx context.Context, r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	writer := bufio.NewWriter(w)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		response, err := s.HandleRequest(ctx, line)
+		if err != nil {
+			s.logger.Error("error processing request", "error", err)
+			continue
+		}
+
+		// Notifications produce no response line
+		if response == nil {
+			continue
+		}
+
+		writer.Write(response)
+		writer.WriteByte('\n')
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
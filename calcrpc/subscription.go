@@ -0,0 +1,178 @@
+package calcrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// SubscriptionMethod is the notification pushed with each subscription result
+const SubscriptionMethod = "calc.subscription"
+
+// Subscription limits per connection
+const (
+	defaultSubscriptionInterval = time.Second
+	minSubscriptionInterval     = 100 * time.Millisecond
+	maxSubscriptionsPerSession  = 16
+)
+
+// SubscribeParams represents parameters for subscribe
+// Method must be a read-only method; it is called with Params every IntervalMs
+type SubscribeParams struct {
+	Method     string          `json:"method"`
+	Params     json.RawMessage `json:"params,omitempty"`
+	IntervalMs int             `json:"intervalMs,omitempty"` // default 1000, minimum 100
+}
+
+// UnsubscribeParams represents parameters for unsubscribe
+type UnsubscribeParams struct {
+	Subscription string `json:"subscription"`
+}
+
+// SubscriptionResult is the payload of a calc.subscription notification
+// Exactly one of Result and Error is set
+type SubscriptionResult struct {
+	Subscription string        `json:"subscription"`
+	Result       interface{}   `json:"result,omitempty"`
+	Error        *JSONRPCError `json:"error,omitempty"`
+}
+
+// session is the per-connection state of a streaming transport (WebSocket)
+// Its subscriptions stop when the session context is cancelled
+type session struct {
+	ctx  context.Context
+	send func(message []byte) error
+
+	mu            sync.Mutex
+	subscriptions map[string]context.CancelFunc
+}
+
+// sessionKey is the context key under which the current session is stored
+type sessionKey struct{}
+
+// withSession attaches a session to ctx for the methods called with it
+func withSession(ctx context.Context, sess *session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, sess)
+}
+
+// newSession creates a session whose pushes go through send
+func newSession(ctx context.Context, send func(message []byte) error) *session {
+	return &session{ctx: ctx, send: send, subscriptions: make(map[string]context.CancelFunc)}
+}
+
+// subscriptionError is returned by subscribe/unsubscribe outside a streaming session
+func subscriptionError() error {
+	return &JSONRPCError{
+		Code:    -32000, // Application error
+		Message: "Subscriptions unavailable",
+		Data:    "subscribe and unsubscribe require a WebSocket connection",
+	}
+}
+
+// subscribe starts pushing the result of a read-only method call periodically
+// and returns the subscription id
+func (s *JSONRPCServer) subscribe(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	sess, ok := ctx.Value(sessionKey{}).(*session)
+	if !ok {
+		return nil, subscriptionError()
+	}
+
+	var p SubscribeParams
+	if err := decodeParams(params, &p, paramsUsage(reflect.TypeOf(p))); err != nil {
+		return nil, err
+	}
+	if !s.isReadOnly(p.Method) {
+		return nil, invalidParams("method", reasonInvalid, "read-only method", fmt.Sprintf("Method '%s' is not a read-only method that can be subscribed to", p.Method))
+	}
+	interval := defaultSubscriptionInterval
+	if p.IntervalMs != 0 {
+		interval = time.Duration(p.IntervalMs) * time.Millisecond
+	}
+	if interval < minSubscriptionInterval {
+		return nil, invalidParams("intervalMs", reasonRange, fmt.Sprintf("integer >= %d", minSubscriptionInterval.Milliseconds()), fmt.Sprintf("Parameter 'intervalMs' must be at least %d, got %d", minSubscriptionInterval.Milliseconds(), p.IntervalMs))
+	}
+
+	id := newCorrelationID()
+	subCtx, cancel := context.WithCancel(sess.ctx)
+	sess.mu.Lock()
+	if len(sess.subscriptions) >= maxSubscriptionsPerSession {
+		sess.mu.Unlock()
+		cancel()
+		return nil, &JSONRPCError{
+			Code:    -32000, // Application error
+			Message: "Too many subscriptions",
+			Data:    fmt.Sprintf("a connection may hold at most %d subscriptions", maxSubscriptionsPerSession),
+		}
+	}
+	sess.subscriptions[id] = cancel
+	sess.mu.Unlock()
+
+	go s.runSubscription(subCtx, sess, id, p.Method, p.Params, interval)
+	s.logger.Info("subscription started", "subscription", id, "method", p.Method, "interval_ms", interval.Milliseconds())
+	return id, nil
+}
+
+// unsubscribe stops a subscription of the current session
+func (s *JSONRPCServer) unsubscribe(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	sess, ok := ctx.Value(sessionKey{}).(*session)
+	if !ok {
+		return nil, subscriptionError()
+	}
+
+	var p UnsubscribeParams
+	if err := decodeParams(params, &p, paramsUsage(reflect.TypeOf(p))); err != nil {
+		return nil, err
+	}
+
+	sess.mu.Lock()
+	cancel, ok := sess.subscriptions[p.Subscription]
+	delete(sess.subscriptions, p.Subscription)
+	sess.mu.Unlock()
+	if !ok {
+		return nil, invalidParams("subscription", reasonInvalid, "id of an active subscription", fmt.Sprintf("Unknown subscription '%s'", p.Subscription))
+	}
+
+	cancel()
+	s.logger.Info("subscription stopped", "subscription", p.Subscription)
+	return true, nil
+}
+
+// runSubscription pushes the method's result every interval until ctx is
+// cancelled by unsubscribe or by the connection closing. The first push waits
+// one interval so the subscribe response, carrying the id, arrives first
+func (s *JSONRPCServer) runSubscription(ctx context.Context, sess *session, id string, method string, params json.RawMessage, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		push := SubscriptionResult{Subscription: id}
+		result, err := s.callMethod(ctx, method, params)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			jsonrpcErr, ok := err.(*JSONRPCError)
+			if !ok {
+				jsonrpcErr = &JSONRPCError{Code: InternalError, Message: "Internal error", Data: err.Error()}
+			}
+			push.Error = s.clientError(s.logger, jsonrpcErr)
+		} else {
+			push.Result = roundResult(result, s.ResultDigits)
+		}
+
+		message, _ := json.Marshal(JSONRPCNotification{JSONRPC: JSONRPCVersion, Method: SubscriptionMethod, Params: push})
+		if err := sess.send(message); err != nil {
+			s.logger.Error("subscription push failed", "subscription", id, "error", err)
+			return
+		}
+	}
+}
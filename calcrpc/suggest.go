@@ -0,0 +1,81 @@
+package calcrpc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// methodNotFoundError reports an unknown method, suggesting the closest
+// registered name or alias when one is near enough to be a likely typo
+func (s *JSONRPCServer) methodNotFoundError(method string) *JSONRPCError {
+	detail := fmt.Sprintf("Method '%s' is not available", method)
+	if suggestion := s.suggestMethod(method); suggestion != "" {
+		detail += fmt.Sprintf("; did you mean '%s'?", suggestion)
+	}
+	return &JSONRPCError{
+		Code:    MethodNotFound,
+		Message: "Method not found",
+		Data:    detail,
+	}
+}
+
+// suggestMethod returns the method or alias closest to name by Levenshtein
+// distance, or "" when none is within a third of the name's length (at least 2,
+// but always less than the length so short names aren't matched to anything).
+// Candidates whose length alone puts them out of reach are skipped, so a huge
+// method name costs no distance computations
+func (s *JSONRPCServer) suggestMethod(name string) string {
+	s.mu.RLock()
+	candidates := make([]string, 0, len(s.methods)+len(s.aliases))
+	longest := 0
+	for method := range s.methods {
+		candidates = append(candidates, method)
+		longest = max(longest, len(method))
+	}
+	for alias := range s.aliases {
+		candidates = append(candidates, alias)
+		longest = max(longest, len(alias))
+	}
+	s.mu.RUnlock()
+
+	limit := min(max(2, len(name)/3), len(name)-1)
+	if len(name) > longest+limit {
+		return ""
+	}
+	lowerName := strings.ToLower(name)
+	best, bestDistance := "", limit+1
+	for _, candidate := range candidates {
+		// The distance is at least the difference in length
+		if diff := len(name) - len(candidate); diff > limit || -diff > limit {
+			continue
+		}
+		distance := levenshtein(lowerName, strings.ToLower(candidate))
+		// Ties go to the alphabetically first name so suggestions are stable
+		if distance < bestDistance || (distance == bestDistance && best != "" && candidate < best) {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between a and b in bytes
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
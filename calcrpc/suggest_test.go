@@ -0,0 +1,25 @@
+package calcrpc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSuggestMethod(t *testing.T) {
+	s := newTestServer()
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"math.ad", "math.add"},
+		{"MATH.SUBTRACT", "math.subtract"},
+		{"zz", ""},
+		{"math.add" + strings.Repeat("x", 10), ""},
+		{strings.Repeat("x", 1<<20), ""},
+	}
+	for _, tt := range tests {
+		if got := s.suggestMethod(tt.name); got != tt.want {
+			t.Errorf("suggestMethod(%.20q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package calcrpc
+
+import (
+	"context"
+	"net"
+)
+
+// TCPTransport serves newline-delimited JSON-RPC messages over TCP
+// Each line is one message (or batch) and each response is written as one line
+type TCPTransport struct {
+	// Addr is the listen address, e.g. ":8091"
+	Addr string
+}
+
+// Serve listens on Addr until ctx is cancelled
+func (t *TCPTransport) Serve(ctx context.Context, rpcServer *JSONRPCServer) error {
+	listener, err := net.Listen("tcp", t.Addr)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+	stop := context.AfterFunc(ctx, func() { listener.Close() })
+	defer stop()
+
+	rpcServer.addTransport(false, "tcp")
+	rpcServer.logger.Info("JSON-RPC TCP endpoint listening", "addr", listener.Addr().String())
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go serveTCPConn(ctx, rpcServer, conn)
+	}
+}
+
+// serveTCPConn handles one TCP client until it disconnects
+func serveTCPConn(ctx context.Context, rpcServer *JSONRPCServer, conn net.Conn) {
+	defer conn.Close()
+	rpcServer.logger.Info("TCP client connected", "remote_addr", conn.RemoteAddr().String())
+
+	// Requests on this connection are cancelled once the client disconnects
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Closing the connection on shutdown unblocks the read loop
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if err := rpcServer.serveStream(ctx, conn, conn); err != nil && ctx.Err() == nil {
+		rpcServer.logger.Error("TCP connection error", "remote_addr", conn.RemoteAddr().String(), "error", err)
+	}
+	rpcServer.logger.Info("TCP client disconnected", "remote_addr", conn.RemoteAddr().String())
+}
@@ -0,0 +1,38 @@
+package calcrpc
+
+import (
+	"context"
+)
+
+// Transport carries JSON-RPC messages between clients and a JSONRPCServer.
+// Implementations own their listeners and connections and hand every payload
+// to HandleRequest (or HandleRequestStream), so the dispatch core stays
+// independent of how messages arrive
+type Transport interface {
+	// Serve blocks serving rpcServer until ctx is cancelled (returning nil)
+	// or the transport fails
+	Serve(ctx context.Context, rpcServer *JSONRPCServer) error
+}
+
+// ServeTransports runs every transport until ctx is cancelled or one of them
+// stops; the others are then cancelled and the first error is returned
+func ServeTransports(ctx context.Context, rpcServer *JSONRPCServer, transports ...Transport) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make(chan error, len(transports))
+	for _, transport := range transports {
+		go func() {
+			errs <- transport.Serve(ctx, rpcServer)
+		}()
+	}
+
+	var first error
+	for range transports {
+		if err := <-errs; err != nil && first == nil {
+			first = err
+		}
+		cancel()
+	}
+	return first
+}
@@ -1,6 +1,7 @@
-package main
+package calcrpc
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 )
@@ -38,10 +39,16 @@ func (n JSONRPCNotification) GetJSONRPC() string {
 
 // JSONRPCResponse represents a JSON-RPC response
 type JSONRPCResponse struct {
-	JSONRPC string      `json:"jsonrpc"`
-	Result  interface{} `json:"result,omitempty"`
+	JSONRPC string        `json:"jsonrpc"`
+	Result  interface{}   `json:"result,omitempty"`
 	Error   *JSONRPCError `json:"error,omitempty"`
-	ID      interface{} `json:"id"`
+	Timing  *Timing       `json:"timing,omitempty"` // only when the request sets "includeTiming"
+	ID      interface{}   `json:"id"`
+}
+
+// Timing reports how long the server spent on a call, in milliseconds
+type Timing struct {
+	MS float64 `json:"ms"`
 }
 
 func (r JSONRPCResponse) GetJSONRPC() string {
@@ -68,8 +75,22 @@ const (
 	InternalError  = -32603
 )
 
+// Server-defined error codes (reserved range -32000 to -32099)
+const (
+	RequestTimeout = -32001
+	Unauthorized   = -32002
+)
+
+// utf8BOM is the byte order mark some clients put before a UTF-8 body
+var utf8BOM = []byte("\xef\xbb\xbf")
+
 // ParseMessage attempts to parse a JSON-RPC message and determine its type
+// Batches are returned as []interface{} whose elements are JSONRPCRequest,
+// JSONRPCNotification, or *JSONRPCError for elements that failed to parse.
+// A leading byte order mark and surrounding whitespace are ignored
 func ParseMessage(data []byte) (interface{}, error) {
+	data = bytes.TrimSpace(bytes.TrimPrefix(bytes.TrimLeft(data, " \t\r\n"), utf8BOM))
+
 	// First, try to determine if it's a batch request (array)
 	if len(data) > 0 && data[0] == '[' {
 		var batch []json.RawMessage
@@ -80,18 +101,38 @@ func ParseMessage(data []byte) (interface{}, error) {
 				Data:    err.Error(),
 			}
 		}
-		
+
+		if len(batch) == 0 {
+			return nil, &JSONRPCError{
+				Code:    InvalidRequest,
+				Message: "Invalid Request",
+				Data:    "batch must contain at least one message",
+			}
+		}
+
+		// Each element is parsed independently; an invalid element is kept as
+		// its *JSONRPCError so it gets its own error response in the batch
 		var messages []interface{}
 		for _, raw := range batch {
 			msg, err := ParseSingleMessage(raw)
 			if err != nil {
-				return nil, err
+				jsonrpcErr := err.(*JSONRPCError)
+				if jsonrpcErr.Code == ParseError {
+					// The batch itself was valid JSON, so the element just isn't a request object
+					jsonrpcErr = &JSONRPCError{
+						Code:    InvalidRequest,
+						Message: "Invalid Request",
+						Data:    jsonrpcErr.Data,
+					}
+				}
+				messages = append(messages, jsonrpcErr)
+				continue
 			}
 			messages = append(messages, msg)
 		}
 		return messages, nil
 	}
-	
+
 	// Single message
 	return ParseSingleMessage(data)
 }
@@ -103,9 +144,19 @@ func ParseSingleMessage(data []byte) (interface{}, error) {
 		JSONRPC string          `json:"jsonrpc"`
 		Method  string          `json:"method"`
 		Params  json.RawMessage `json:"params,omitempty"`
-		ID      *json.RawMessage `json:"id,omitempty"` // Pointer to detect presence
+		ID      json.RawMessage `json:"id,omitempty"` // nil when absent, "null" when explicitly null
 	}
-	
+
+	// Well-formed JSON that isn't an object (42, "hello", true, null) is a
+	// valid payload but not a request
+	if trimmed := bytes.TrimSpace(data); json.Valid(trimmed) && trimmed[0] != '{' {
+		return nil, &JSONRPCError{
+			Code:    InvalidRequest,
+			Message: "Invalid Request",
+			Data:    "request must be an object or array",
+		}
+	}
+
 	if err := json.Unmarshal(data, &raw); err != nil {
 		return nil, &JSONRPCError{
 			Code:    ParseError,
@@ -113,7 +164,7 @@ func ParseSingleMessage(data []byte) (interface{}, error) {
 			Data:    err.Error(),
 		}
 	}
-	
+
 	// Validate common fields
 	if raw.JSONRPC != JSONRPCVersion {
 		return nil, &JSONRPCError{
@@ -122,7 +173,7 @@ func ParseSingleMessage(data []byte) (interface{}, error) {
 			Data:    "jsonrpc field must be '2.0'",
 		}
 	}
-	
+
 	if raw.Method == "" {
 		return nil, &JSONRPCError{
 			Code:    InvalidRequest,
@@ -130,11 +181,14 @@ func ParseSingleMessage(data []byte) (interface{}, error) {
 			Data:    "method field is required",
 		}
 	}
-	
+
 	// Parse params once (same for both request and notification)
+	// Numbers stay json.Number so integers beyond 2^53 reach handlers exactly
 	var params interface{}
 	if raw.Params != nil {
-		if err := json.Unmarshal(raw.Params, &params); err != nil {
+		decoder := json.NewDecoder(bytes.NewReader(raw.Params))
+		decoder.UseNumber()
+		if err := decoder.Decode(&params); err != nil {
 			return nil, &JSONRPCError{
 				Code:    InvalidRequest,
 				Message: "Invalid Request",
@@ -142,19 +196,35 @@ func ParseSingleMessage(data []byte) (interface{}, error) {
 			}
 		}
 	}
-	
+
 	// Only difference: check ID at the end to determine type
+	// An absent id means a notification, but "id": null is still a request
+	// (null is a valid id) and must get a response carrying "id": null
 	if raw.ID != nil {
 		// It's a request (has ID, expects response)
+		// Decode numbers as json.Number so integer ids round-trip exactly
 		var id interface{}
-		if err := json.Unmarshal(*raw.ID, &id); err != nil {
+		decoder := json.NewDecoder(bytes.NewReader(raw.ID))
+		decoder.UseNumber()
+		if err := decoder.Decode(&id); err != nil {
 			return nil, &JSONRPCError{
 				Code:    InvalidRequest,
 				Message: "Invalid Request",
 				Data:    "Invalid ID field",
 			}
 		}
-		
+
+		// JSON-RPC 2.0 only allows string, number, or null ids
+		switch id.(type) {
+		case nil, string, json.Number:
+		default:
+			return nil, &JSONRPCError{
+				Code:    InvalidRequest,
+				Message: "Invalid Request",
+				Data:    "id must be a string, number, or null",
+			}
+		}
+
 		return JSONRPCRequest{
 			JSONRPC: raw.JSONRPC,
 			Method:  raw.Method,
@@ -162,7 +232,7 @@ func ParseSingleMessage(data []byte) (interface{}, error) {
 			ID:      id,
 		}, nil
 	}
-	
+
 	// No ID = notification (no response expected)
 	return JSONRPCNotification{
 		JSONRPC: raw.JSONRPC,
@@ -187,4 +257,4 @@ func CreateErrorResponse(err *JSONRPCError, id interface{}) JSONRPCResponse {
 		Error:   err,
 		ID:      id,
 	}
-}
\ No newline at end of file
+}
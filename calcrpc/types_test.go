@@ -0,0 +1,116 @@
+package calcrpc
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNullIDIsARequest(t *testing.T) {
+	msg, err := ParseSingleMessage([]byte(`{"jsonrpc":"2.0","method":"add","params":[1,2],"id":null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, ok := msg.(JSONRPCRequest)
+	if !ok || request.ID != nil {
+		t.Fatalf("parsed %#v, want a JSONRPCRequest with a nil id", msg)
+	}
+
+	msg, err = ParseSingleMessage([]byte(`{"jsonrpc":"2.0","method":"add","params":[1,2]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := msg.(JSONRPCNotification); !ok {
+		t.Fatalf("parsed %#v, want a JSONRPCNotification", msg)
+	}
+
+	s := newTestServer()
+	if out := call(t, s, `{"jsonrpc":"2.0","method":"add","params":[1,2],"id":null}`); string(out) != `{"jsonrpc":"2.0","result":3,"id":null}` {
+		t.Errorf("response to null id = %s", out)
+	}
+	if out := call(t, s, `{"jsonrpc":"2.0","method":"add","params":[1,2]}`); len(out) != 0 {
+		t.Errorf("response to notification = %s, want none", out)
+	}
+}
+
+func TestIDTypes(t *testing.T) {
+	s := newTestServer()
+	tests := []struct {
+		name  string
+		id    string
+		valid bool
+	}{
+		{"object", `{"x":1}`, false},
+		{"array", `[1]`, false},
+		{"boolean", `true`, false},
+		{"string", `"abc"`, true},
+		{"integer", `42`, true},
+		{"float", `1.5`, true},
+		{"null", `null`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"jsonrpc":"2.0","method":"add","params":[1,2],"id":` + tt.id + `}`
+			_, err := ParseSingleMessage([]byte(body))
+			if tt.valid != (err == nil) {
+				t.Fatalf("ParseSingleMessage(id %s) err = %v, want valid %v", tt.id, err, tt.valid)
+			}
+
+			response := callResponse(t, s, body)
+			if !tt.valid {
+				if response.Error == nil || response.Error.Code != InvalidRequest || string(response.ID) != "null" {
+					t.Errorf("response = %+v, want InvalidRequest with a null id", response)
+				}
+				return
+			}
+			if response.Error != nil || string(response.ID) != tt.id {
+				t.Errorf("response = error %v id %s, want a result echoing id %s", response.Error, response.ID, tt.id)
+			}
+		})
+	}
+}
+
+func TestParseMessageIgnoresBOMAndWhitespace(t *testing.T) {
+	s := newTestServer()
+	single := `{"jsonrpc":"2.0","method":"add","params":[1,2],"id":1}`
+	batch := `[` + single + `,{"jsonrpc":"2.0","method":"subtract","params":[5,2],"id":2}]`
+	tests := []struct {
+		name      string
+		body      string
+		batchSize int // 0 for a single request
+	}{
+		{"BOM", "\ufeff" + single, 0},
+		{"leading spaces", "   \n\t" + single, 0},
+		{"trailing newline", single + "\r\n", 0},
+		{"spaces around BOM", " \ufeff " + single + "\n", 0},
+		{"BOM batch", "\ufeff" + batch, 2},
+		{"leading spaces batch", "  \n" + batch + "\n", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := ParseMessage([]byte(tt.body))
+			if err != nil {
+				t.Fatalf("ParseMessage: %v", err)
+			}
+			if tt.batchSize == 0 {
+				if _, ok := msg.(JSONRPCRequest); !ok {
+					t.Fatalf("parsed %#v, want a JSONRPCRequest", msg)
+				}
+				if code := callError(t, s, tt.body); code != 0 {
+					t.Errorf("request failed with %d", code)
+				}
+				return
+			}
+
+			if messages, ok := msg.([]interface{}); !ok || len(messages) != tt.batchSize {
+				t.Fatalf("parsed %#v, want a batch of %d", msg, tt.batchSize)
+			}
+			var responses []testResponse
+			if err := json.Unmarshal(call(t, s, tt.body), &responses); err != nil {
+				t.Fatal(err)
+			}
+			if len(responses) != tt.batchSize || responses[0].Error != nil || responses[1].Error != nil {
+				t.Errorf("responses = %+v, want %d results", responses, tt.batchSize)
+			}
+		})
+	}
+}
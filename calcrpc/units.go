@@ -0,0 +1,94 @@
+package calcrpc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// unit converts to and from its dimension's base unit (celsius, meter, kilogram)
+// as base = (value + offset) * num / den. The ratio is kept as two exact
+// numbers so round trips like 100 °C -> 212 °F don't pick up rounding errors
+type unit struct {
+	dimension string
+	offset    float64
+	num, den  float64
+}
+
+// units is the conversion table for convert, keyed by lowercase unit name
+var units = map[string]unit{
+	"celsius":    {dimension: "temperature", num: 1, den: 1},
+	"fahrenheit": {dimension: "temperature", offset: -32, num: 5, den: 9},
+	"kelvin":     {dimension: "temperature", offset: -273.15, num: 1, den: 1},
+
+	"mm": {dimension: "length", num: 1, den: 1000},
+	"cm": {dimension: "length", num: 1, den: 100},
+	"m":  {dimension: "length", num: 1, den: 1},
+	"km": {dimension: "length", num: 1000, den: 1},
+	"in": {dimension: "length", num: 254, den: 10000},
+	"ft": {dimension: "length", num: 3048, den: 10000},
+	"yd": {dimension: "length", num: 9144, den: 10000},
+	"mi": {dimension: "length", num: 1609344, den: 1000},
+
+	"mg": {dimension: "weight", num: 1, den: 1000000},
+	"g":  {dimension: "weight", num: 1, den: 1000},
+	"kg": {dimension: "weight", num: 1, den: 1},
+	"t":  {dimension: "weight", num: 1000, den: 1},
+	"oz": {dimension: "weight", num: 28349523125, den: 1000000000000},
+	"lb": {dimension: "weight", num: 45359237, den: 100000000},
+}
+
+// ConvertParams represents parameters for unit conversion
+type ConvertParams struct {
+	Value float64 `json:"value"`
+	From  string  `json:"from"`
+	To    string  `json:"to"`
+}
+
+// Validate ensures both units are known and measure the same dimension
+func (p *ConvertParams) Validate() error {
+	from, err := lookupUnit("from", p.From)
+	if err != nil {
+		return err
+	}
+	to, err := lookupUnit("to", p.To)
+	if err != nil {
+		return err
+	}
+	if from.dimension != to.dimension {
+		return invalidParams("to", reasonInvalid, from.dimension+" unit",
+			fmt.Sprintf("Cannot convert %s (%s) to %s (%s)", p.From, from.dimension, p.To, to.dimension))
+	}
+	return nil
+}
+
+// lookupUnit finds a unit by case-insensitive name
+func lookupUnit(field string, name string) (unit, error) {
+	u, ok := units[strings.ToLower(name)]
+	if !ok {
+		return unit{}, invalidParams(field, reasonInvalid, "known unit",
+			fmt.Sprintf("Unknown unit %q; supported units: %s", name, strings.Join(unitNames(), ", ")))
+	}
+	return u, nil
+}
+
+// unitNames returns the supported unit names, sorted
+func unitNames() []string {
+	names := make([]string, 0, len(units))
+	for name := range units {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Convert converts value between two units of the same dimension
+func (c *Calculator) Convert(params ConvertParams) (float64, error) {
+	from, _ := lookupUnit("from", params.From)
+	to, _ := lookupUnit("to", params.To)
+
+	base := (params.Value + from.offset) * from.num / from.den
+	result := base*to.den/to.num - to.offset
+	c.logger.Debug("calculation", "operation", "convert", "value", params.Value, "from", params.From, "to", params.To, "result", result)
+	return result, nil
+}
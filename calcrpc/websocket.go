@@ -0,0 +1,93 @@
+package calcrpc
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// serveWebSocket returns a handler that speaks JSON-RPC over a WebSocket connection
+// Each text frame is one JSON-RPC message (or batch); responses are written back as
+// text frames and notifications produce no frame. Server-pushed notifications
+// (see Broadcast) are written to the same connection as they occur.
+// A frame larger than maxMessageBytes closes the connection.
+// Browsers may only connect from the origins allowed by corsOrigins, as for
+// HTTP; clients that send no Origin (i.e. not browsers) are always accepted
+func serveWebSocket(rpcServer *JSONRPCServer, corsOrigins []string, maxMessageBytes int64) http.HandlerFunc {
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			return origin == "" || allowedOrigin(corsOrigins, origin) != ""
+		},
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			rpcServer.logger.Error("WebSocket upgrade failed", "error", err)
+			return
+		}
+		defer conn.Close()
+		conn.SetReadLimit(maxMessageBytes)
+
+		rpcServer.logger.Info("WebSocket client connected", "remote_addr", r.RemoteAddr)
+
+		// Cancelled on disconnect, which stops every subscription of this connection
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+
+		// Responses and pushes share the connection, which allows one writer at a time
+		var writeMu sync.Mutex
+		write := func(message []byte) error {
+			writeMu.Lock()
+			defer writeMu.Unlock()
+			return conn.WriteMessage(websocket.TextMessage, message)
+		}
+
+		ctx = withSession(ctx, newSession(ctx, write))
+
+		pushes, unsubscribe := rpcServer.Subscribe()
+		defer unsubscribe()
+		go func() {
+			for message := range pushes {
+				if err := write(message); err != nil {
+					rpcServer.logger.Error("WebSocket push error", "remote_addr", r.RemoteAddr, "error", err)
+				}
+			}
+		}()
+
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+					rpcServer.logger.Error("WebSocket read error", "remote_addr", r.RemoteAddr, "error", err)
+				}
+				break
+			}
+
+			if messageType != websocket.TextMessage {
+				continue
+			}
+
+			// Process JSON-RPC message with the same dispatch as HTTP
+			response, err := rpcServer.HandleRequest(ctx, data)
+			if err != nil {
+				rpcServer.logger.Error("error processing request", "error", err)
+				continue
+			}
+
+			// Notifications produce no response frame
+			if response == nil {
+				continue
+			}
+
+			if err := write(response); err != nil {
+				rpcServer.logger.Error("WebSocket write error", "remote_addr", r.RemoteAddr, "error", err)
+				break
+			}
+		}
+		rpcServer.logger.Info("WebSocket client disconnected", "remote_addr", r.RemoteAddr)
+	}
+}
@@ -0,0 +1,74 @@
+package calcrpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+// webSocketURL serves the /ws handler of s, allowing corsOrigins, and returns its URL
+func webSocketURL(t *testing.T, s *JSONRPCServer, corsOrigins []string) string {
+	t.Helper()
+	server := httptest.NewServer(serveWebSocket(s, corsOrigins, DefaultMaxBodyBytes))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+// dialWebSocket connects to the /ws handler of s served by a test server
+func dialWebSocket(t *testing.T, s *JSONRPCServer) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial(webSocketURL(t, s, []string{"*"}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestWebSocketOversizedFrameClosesConnection(t *testing.T) {
+	conn := dialWebSocket(t, newTestServer())
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"add","params":[1,2],"id":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, response, err := conn.ReadMessage(); err != nil || string(response) != `{"jsonrpc":"2.0","result":3,"id":1}` {
+		t.Fatalf("response = %s, %v", response, err)
+	}
+
+	frame := `{"jsonrpc":"2.0","method":"add","params":[1,2],"id":2,"pad":"` + strings.Repeat("x", DefaultMaxBodyBytes) + `"}`
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(frame)); err != nil {
+		t.Fatal(err)
+	}
+	_, response, err := conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+		t.Fatalf("read after oversized frame = %s, %v, want close %d", response, err, websocket.CloseMessageTooBig)
+	}
+}
+
+func TestWebSocketOriginFollowsCORSOrigins(t *testing.T) {
+	url := webSocketURL(t, newTestServer(), []string{"https://app.example.com"})
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.com", true},
+		{"https://evil.example.com", false},
+		{"", true}, // not a browser
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		if tt.origin != "" {
+			header.Set("Origin", tt.origin)
+		}
+		conn, _, err := websocket.DefaultDialer.Dial(url, header)
+		if got := err == nil; got != tt.want {
+			t.Errorf("origin %q: connected = %v, want %v (%v)", tt.origin, got, tt.want, err)
+		}
+		if conn != nil {
+			conn.Close()
+		}
+	}
+}
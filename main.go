@@ -1,99 +1,386 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
+	"log/slog"
+	"os"
+	"strconv"
 	"strings"
+	"time"
+
+	"simple-jsonrpc-calculator/calcrpc"
 )
 
-func main() {
-	// Create JSON-RPC server
-	rpcServer := NewJSONRPCServer()
-	
-	// HTTP handler for JSON-RPC
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		// Set CORS headers for web testing
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-		
-		// Handle OPTIONS request for CORS preflight
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-		
-		// Only accept POST requests
-		if r.Method != "POST" {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			w.Write([]byte(`{"error": "Only POST method is allowed for JSON-RPC"}`))
-			return
-		}
-		
-		// Check content type
-		contentType := r.Header.Get("Content-Type")
-		if !strings.Contains(contentType, "application/json") {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write([]byte(`{"error": "Content-Type must be application/json"}`))
-			return
+// defaultPort is used when neither CALC_PORT nor -port is set
+const defaultPort = 8090
+
+// portFromEnv reads the listen port from CALC_PORT, falling back to defaultPort
+func portFromEnv() (int, error) {
+	value := os.Getenv("CALC_PORT")
+	if value == "" {
+		return defaultPort, nil
+	}
+
+	port, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("CALC_PORT must be a number, got %q", value)
+	}
+	return port, nil
+}
+
+// requestTimeoutFromEnv reads the per-request timeout from CALC_REQUEST_TIMEOUT
+// (a Go duration such as "5s" or "500ms"), falling back to calcrpc.DefaultRequestTimeout
+func requestTimeoutFromEnv() (time.Duration, error) {
+	return durationFromEnv("CALC_REQUEST_TIMEOUT", calcrpc.DefaultRequestTimeout)
+}
+
+// maxRequestTimeoutFromEnv reads the cap on X-Request-Timeout-Ms from
+// CALC_MAX_REQUEST_TIMEOUT, falling back to calcrpc.DefaultMaxRequestTimeout
+func maxRequestTimeoutFromEnv() (time.Duration, error) {
+	return durationFromEnv("CALC_MAX_REQUEST_TIMEOUT", calcrpc.DefaultMaxRequestTimeout)
+}
+
+// httpTimeoutsFromEnv reads the HTTP connection timeouts from
+// CALC_HTTP_READ_HEADER_TIMEOUT, CALC_HTTP_READ_TIMEOUT, CALC_HTTP_WRITE_TIMEOUT
+// and CALC_HTTP_IDLE_TIMEOUT into transport
+func httpTimeoutsFromEnv(transport *calcrpc.HTTPTransport) error {
+	var err error
+	if transport.ReadHeaderTimeout, err = durationFromEnv("CALC_HTTP_READ_HEADER_TIMEOUT", calcrpc.DefaultHTTPReadHeaderTimeout); err != nil {
+		return err
+	}
+	if transport.ReadTimeout, err = durationFromEnv("CALC_HTTP_READ_TIMEOUT", calcrpc.DefaultHTTPReadTimeout); err != nil {
+		return err
+	}
+	if transport.WriteTimeout, err = durationFromEnv("CALC_HTTP_WRITE_TIMEOUT", calcrpc.DefaultHTTPWriteTimeout); err != nil {
+		return err
+	}
+	transport.IdleTimeout, err = durationFromEnv("CALC_HTTP_IDLE_TIMEOUT", calcrpc.DefaultHTTPIdleTimeout)
+	return err
+}
+
+// durationFromEnv reads a positive Go duration from the named variable, or fallback if unset
+func durationFromEnv(name string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("%s must be a positive duration like \"5s\", got %q", name, value)
+	}
+	return timeout, nil
+}
+
+// logLevelFromEnv reads the minimum log level from CALC_LOG_LEVEL (DEBUG, INFO,
+// WARN or ERROR, any case), defaulting to INFO. Per-operation and per-request
+// details are logged at DEBUG
+func logLevelFromEnv() (slog.Level, error) {
+	value := os.Getenv("CALC_LOG_LEVEL")
+	if value == "" {
+		return slog.LevelInfo, nil
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		return 0, fmt.Errorf("CALC_LOG_LEVEL must be DEBUG, INFO, WARN or ERROR, got %q", value)
+	}
+	return level, nil
+}
+
+// boolFromEnv reads an on/off setting such as CALC_VERBOSE_ERRORS, defaulting to false
+func boolFromEnv(name string) (bool, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return false, nil
+	}
+
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false, got %q", name, value)
+	}
+	return enabled, nil
+}
+
+// idempotencyTTLFromEnv reads how long idempotent results are replayed from
+// CALC_IDEMPOTENCY_TTL; "0" disables idempotency keys
+func idempotencyTTLFromEnv() (time.Duration, error) {
+	if os.Getenv("CALC_IDEMPOTENCY_TTL") == "0" {
+		return 0, nil
+	}
+	return durationFromEnv("CALC_IDEMPOTENCY_TTL", calcrpc.DefaultIdempotencyTTL)
+}
+
+// maxBatchFromEnv reads the batch size limit from CALC_MAX_BATCH (0 disables it)
+func maxBatchFromEnv() (int, error) {
+	value := os.Getenv("CALC_MAX_BATCH")
+	if value == "" {
+		return calcrpc.DefaultMaxBatchSize, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		return 0, fmt.Errorf("CALC_MAX_BATCH must be a non-negative number, got %q", value)
+	}
+	return limit, nil
+}
+
+// maxExprDepthFromEnv reads CALC_MAX_EXPR_DEPTH, the nesting limit of eval expressions
+func maxExprDepthFromEnv() (int, error) {
+	value := os.Getenv("CALC_MAX_EXPR_DEPTH")
+	if value == "" {
+		return calcrpc.DefaultMaxExprDepth, nil
+	}
+
+	depth, err := strconv.Atoi(value)
+	if err != nil || depth < 1 {
+		return 0, fmt.Errorf("CALC_MAX_EXPR_DEPTH must be a positive number, got %q", value)
+	}
+	return depth, nil
+}
+
+// batchWorkersFromEnv reads CALC_BATCH_WORKERS, the number of batch entries
+// processed concurrently; unset (0) uses GOMAXPROCS
+func batchWorkersFromEnv() (int, error) {
+	value := os.Getenv("CALC_BATCH_WORKERS")
+	if value == "" {
+		return 0, nil
+	}
+
+	workers, err := strconv.Atoi(value)
+	if err != nil || workers < 1 {
+		return 0, fmt.Errorf("CALC_BATCH_WORKERS must be a positive number, got %q", value)
+	}
+	return workers, nil
+}
+
+// resultDigitsFromEnv reads CALC_RESULT_DIGITS, the significant digits kept in
+// float results; unset (0) keeps full precision
+func resultDigitsFromEnv() (int, error) {
+	value := os.Getenv("CALC_RESULT_DIGITS")
+	if value == "" {
+		return 0, nil
+	}
+
+	digits, err := strconv.Atoi(value)
+	if err != nil || digits < 1 || digits > 17 {
+		return 0, fmt.Errorf("CALC_RESULT_DIGITS must be a number from 1 to 17, got %q", value)
+	}
+	return digits, nil
+}
+
+// maxBodyBytesFromEnv reads the request body limit from CALC_MAX_BODY_BYTES
+func maxBodyBytesFromEnv() (int64, error) {
+	value := os.Getenv("CALC_MAX_BODY_BYTES")
+	if value == "" {
+		return calcrpc.DefaultMaxBodyBytes, nil
+	}
+
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || limit <= 0 {
+		return 0, fmt.Errorf("CALC_MAX_BODY_BYTES must be a positive number of bytes, got %q", value)
+	}
+	return limit, nil
+}
+
+// flagSet reports whether the named flag was given on the command line
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
 		}
-		
-		// Read request body
-		body, err := io.ReadAll(r.Body)
-		if err != nil {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write([]byte(`{"error": "Cannot read request body"}`))
-			return
+	})
+	return set
+}
+
+// tlsFilesFromEnv reads the certificate and key files from CALC_TLS_CERT and
+// CALC_TLS_KEY; both or neither must be set
+func tlsFilesFromEnv() (certFile string, keyFile string, err error) {
+	certFile, keyFile = os.Getenv("CALC_TLS_CERT"), os.Getenv("CALC_TLS_KEY")
+	if (certFile == "") != (keyFile == "") {
+		return "", "", fmt.Errorf("CALC_TLS_CERT and CALC_TLS_KEY must be set together")
+	}
+	return certFile, keyFile, nil
+}
+
+// corsOriginsFromEnv reads the comma-separated CORS allowlist from CALC_CORS_ORIGINS
+// Unset keeps the historical wildcard; "*" in the list allows any origin
+func corsOriginsFromEnv() []string {
+	value, ok := os.LookupEnv("CALC_CORS_ORIGINS")
+	if !ok {
+		return []string{"*"}
+	}
+	var origins []string
+	for _, origin := range strings.Split(value, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
 		}
-		defer r.Body.Close()
-		
-		// Process JSON-RPC request
-		response, err := rpcServer.HandleRequest(body)
-		if err != nil {
-			log.Printf("Error processing request: %v", err)
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte(`{"error": "Internal server error"}`))
-			return
+	}
+	return origins
+}
+
+func main() {
+	// Resolve listen port: -port flag, then CALC_PORT, then the default
+	envPort, err := portFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	var port int
+	var tcpAddr string
+	flag.IntVar(&port, "port", envPort, "port to listen on (overrides CALC_PORT)")
+	var stdio bool
+	flag.StringVar(&tcpAddr, "tcp", ":8091", "address for the newline-delimited TCP transport (empty to disable)")
+	flag.BoolVar(&stdio, "stdio", false, "serve JSON-RPC on stdin/stdout instead of HTTP")
+	var lenientDefaults bool
+	flag.BoolVar(&lenientDefaults, "lenient-defaults", false, "fill a missing b with the operation's identity element (0 for add/subtract, 1 for multiply/divide)")
+	flag.Parse()
+
+	if port < 1 || port > 65535 {
+		log.Fatalf("Invalid configuration: port must be between 1 and 65535, got %d", port)
+	}
+
+	requestTimeout, err := requestTimeoutFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	maxRequestTimeout, err := maxRequestTimeoutFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	maxBodyBytes, err := maxBodyBytesFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	verboseErrors, err := boolFromEnv("CALC_VERBOSE_ERRORS")
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	structuredParamErrors, err := boolFromEnv("CALC_STRUCTURED_PARAM_ERRORS")
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	idempotencyTTL, err := idempotencyTTLFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	maxBatch, err := maxBatchFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	batchWorkers, err := batchWorkersFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	resultDigits, err := resultDigitsFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	maxExprDepth, err := maxExprDepthFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// Spec-pure HTTP status (always 200) unless error statuses are requested
+	httpErrorStatus, err := boolFromEnv("CALC_HTTP_ERROR_STATUS")
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	logLevel, err := logLevelFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// Structured JSON logs on stderr; log.Printf output is routed through the same handler
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel}))
+	slog.SetDefault(logger)
+
+	// Bearer token required on the JSON-RPC endpoints when set
+	apiKey := os.Getenv("CALC_API_KEY")
+
+	// The TCP transport can't carry the key, so it would bypass it: asking for
+	// it explicitly is an error, and the default listener is left off
+	if apiKey != "" && tcpAddr != "" {
+		if flagSet("tcp") {
+			log.Fatalf("Invalid configuration: the TCP transport is unauthenticated and can't be enabled with CALC_API_KEY")
 		}
-		
-		// Set response headers
-		w.Header().Set("Content-Type", "application/json")
-		
-		// Handle notifications (no response)
-		if response == nil {
-			w.WriteHeader(http.StatusNoContent)
-			return
+		log.Printf("TCP transport disabled because CALC_API_KEY is set")
+		tcpAddr = ""
+	}
+
+	// Origins allowed to call the HTTP endpoint from a browser
+	corsOrigins := corsOriginsFromEnv()
+
+	// HTTPS (with HTTP/2 over TLS) when a certificate is configured
+	certFile, keyFile, err := tlsFilesFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// Create JSON-RPC server
+	rpcServer := calcrpc.NewJSONRPCServer(logger)
+	rpcServer.RequestTimeout = requestTimeout
+	rpcServer.MaxRequestTimeout = maxRequestTimeout
+	rpcServer.VerboseErrors = verboseErrors
+	rpcServer.StructuredParamErrors = structuredParamErrors
+	rpcServer.LenientDefaults = lenientDefaults
+	rpcServer.ResultDigits = resultDigits
+	rpcServer.MaxExprDepth = maxExprDepth
+	rpcServer.MaxBatchSize = maxBatch
+	rpcServer.IdempotencyTTL = idempotencyTTL
+	rpcServer.BatchWorkers = batchWorkers
+
+	// Subprocess mode: speak JSON-RPC over stdin/stdout and skip network transports
+	if stdio {
+		if err := calcrpc.ServeTransports(context.Background(), rpcServer, &calcrpc.StdioTransport{}); err != nil {
+			log.Fatalf("Stdio transport failed: %v", err)
 		}
-		
-		// Send JSON-RPC response
-		w.WriteHeader(http.StatusOK)
-		w.Write(response)
-	})
-	
-	// Health check endpoint
-	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status": "healthy", "service": "JSON-RPC Calculator"}`))
-	})
-	
+		return
+	}
+
+	// HTTP (with WebSocket, metrics and health endpoints), plus TCP alongside it
+	httpTransport := &calcrpc.HTTPTransport{
+		Addr:            fmt.Sprintf(":%d", port),
+		CertFile:        certFile,
+		KeyFile:         keyFile,
+		APIKey:          apiKey,
+		CORSOrigins:     corsOrigins,
+		MaxBodyBytes:    maxBodyBytes,
+		HTTPErrorStatus: httpErrorStatus,
+	}
+	if err := httpTimeoutsFromEnv(httpTransport); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	transports := []calcrpc.Transport{httpTransport}
+	if tcpAddr != "" {
+		transports = append(transports, &calcrpc.TCPTransport{Addr: tcpAddr})
+	}
+
 	// Start server
-	port := 8090
 	log.Printf("JSON-RPC Calculator Server starting on port %d", port)
 	log.Printf("Health check available at: http://localhost:%d/health", port)
+	log.Printf("Metrics available at: http://localhost:%d/metrics", port)
 	log.Printf("JSON-RPC endpoint at: http://localhost:%d/", port)
+	log.Printf("WebSocket endpoint at: ws://localhost:%d/ws", port)
 	log.Println("")
 	log.Println("Example curl commands:")
 	log.Printf(`  curl -X POST -H "Content-Type: application/json" -d '{"jsonrpc":"2.0","method":"add","params":{"a":10,"b":20},"id":1}' http://localhost:%d/`, port)
-	log.Printf(`  curl -X POST -H "Content-Type: application/json" -d '{"jsonrpc":"2.0","method":"log","params":{"message":"Hello from curl!"}}' http://localhost:%d/`, port)
-	
-	if err := http.ListenAndServe(fmt.Sprintf(":%d", port), nil); err != nil {
-		log.Fatalf("Server failed to start: %v", err)
+	log.Printf(`  curl -X POST -H "Content-Type: application/json" -d '{"jsonrpc":"2.0","method":"logMessage","params":{"message":"Hello from curl!"}}' http://localhost:%d/`, port)
+
+	if err := calcrpc.ServeTransports(context.Background(), rpcServer, transports...); err != nil {
+		log.Fatalf("Server failed: %v", err)
 	}
-}
\ No newline at end of file
+}